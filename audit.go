@@ -0,0 +1,46 @@
+package dazeus
+
+import "time"
+
+// AuditEntry records a reply sent in response to an event, see Event.ReplyLogged
+type AuditEntry struct {
+	Time    time.Time
+	Network string
+	Channel string
+	Sender  string
+	// Command is the command that was invoked, empty for events other than commands
+	Command string
+	// Params are the parameters of the event, for commands the arguments given
+	Params []string
+	Reply  string
+	// Err is the error sending the reply, if any
+	Err error
+}
+
+// AuditSink records the interactions of the bot for auditing
+type AuditSink interface {
+	// Record stores a single interaction
+	Record(entry AuditEntry)
+}
+
+// ReplyLogged replies to the event like Reply, and records the event and the reply in the audit sink. Without an
+// audit sink it only replies.
+func (event *Event) ReplyLogged(message string, highlight bool) error {
+	err := event.Reply(message, highlight)
+
+	sink := event.DaZeus.auditSink
+	if sink != nil {
+		sink.Record(AuditEntry{
+			Time:    event.DaZeus.clock.Now(),
+			Network: event.Network,
+			Channel: event.Channel,
+			Sender:  event.Sender,
+			Command: event.Command,
+			Params:  append([]string(nil), event.Params...),
+			Reply:   message,
+			Err:     err,
+		})
+	}
+
+	return err
+}
@@ -0,0 +1,23 @@
+package dazeus
+
+import "time"
+
+// Clock tells the time for everything time-dependent in the library, such as rate limits and timestamps. It can be
+// replaced with WithClock, for example by a fake clock in tests.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration to pass and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock telling the actual time
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,127 @@
+package dazeus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// GetConfigInt gets a config value from the DaZeus core as an integer, it fails when the value is not an integer.
+func (dazeus *DaZeus) GetConfigInt(key string, group string) (int, error) {
+	return dazeus.GetConfigIntContext(context.Background(), key, group)
+}
+
+// GetConfigIntContext is like GetConfigInt, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetConfigIntContext(ctx context.Context, key string, group string) (int, error) {
+	value, err := dazeus.GetConfigContext(ctx, key, group)
+	if err != nil {
+		return 0, err
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("Config %s is not an integer: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// GetPluginConfigInt gets a config value for the plugin from the DaZeus core as an integer.
+func (dazeus *DaZeus) GetPluginConfigInt(key string) (int, error) {
+	return dazeus.GetPluginConfigIntContext(context.Background(), key)
+}
+
+// GetPluginConfigIntContext is like GetPluginConfigInt, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetPluginConfigIntContext(ctx context.Context, key string) (int, error) {
+	return dazeus.GetConfigIntContext(ctx, key, "plugin")
+}
+
+// GetCoreConfigInt gets a config value for the DaZeus core as an integer.
+func (dazeus *DaZeus) GetCoreConfigInt(key string) (int, error) {
+	return dazeus.GetCoreConfigIntContext(context.Background(), key)
+}
+
+// GetCoreConfigIntContext is like GetCoreConfigInt, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetCoreConfigIntContext(ctx context.Context, key string) (int, error) {
+	return dazeus.GetConfigIntContext(ctx, key, "core")
+}
+
+// GetConfigBool gets a config value from the DaZeus core as a boolean, it fails when the value is not a boolean.
+func (dazeus *DaZeus) GetConfigBool(key string, group string) (bool, error) {
+	return dazeus.GetConfigBoolContext(context.Background(), key, group)
+}
+
+// GetConfigBoolContext is like GetConfigBool, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetConfigBoolContext(ctx context.Context, key string, group string) (bool, error) {
+	value, err := dazeus.GetConfigContext(ctx, key, group)
+	if err != nil {
+		return false, err
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("Config %s is not a boolean: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// GetPluginConfigBool gets a config value for the plugin from the DaZeus core as a boolean.
+func (dazeus *DaZeus) GetPluginConfigBool(key string) (bool, error) {
+	return dazeus.GetPluginConfigBoolContext(context.Background(), key)
+}
+
+// GetPluginConfigBoolContext is like GetPluginConfigBool, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetPluginConfigBoolContext(ctx context.Context, key string) (bool, error) {
+	return dazeus.GetConfigBoolContext(ctx, key, "plugin")
+}
+
+// GetCoreConfigBool gets a config value for the DaZeus core as a boolean.
+func (dazeus *DaZeus) GetCoreConfigBool(key string) (bool, error) {
+	return dazeus.GetCoreConfigBoolContext(context.Background(), key)
+}
+
+// GetCoreConfigBoolContext is like GetCoreConfigBool, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetCoreConfigBoolContext(ctx context.Context, key string) (bool, error) {
+	return dazeus.GetConfigBoolContext(ctx, key, "core")
+}
+
+// GetConfigFloat gets a config value from the DaZeus core as a number, it fails when the value is not a number.
+func (dazeus *DaZeus) GetConfigFloat(key string, group string) (float64, error) {
+	return dazeus.GetConfigFloatContext(context.Background(), key, group)
+}
+
+// GetConfigFloatContext is like GetConfigFloat, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetConfigFloatContext(ctx context.Context, key string, group string) (float64, error) {
+	value, err := dazeus.GetConfigContext(ctx, key, group)
+	if err != nil {
+		return 0, err
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Config %s is not a number: %w", key, err)
+	}
+
+	return parsed, nil
+}
+
+// GetPluginConfigFloat gets a config value for the plugin from the DaZeus core as a number.
+func (dazeus *DaZeus) GetPluginConfigFloat(key string) (float64, error) {
+	return dazeus.GetPluginConfigFloatContext(context.Background(), key)
+}
+
+// GetPluginConfigFloatContext is like GetPluginConfigFloat, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetPluginConfigFloatContext(ctx context.Context, key string) (float64, error) {
+	return dazeus.GetConfigFloatContext(ctx, key, "plugin")
+}
+
+// GetCoreConfigFloat gets a config value for the DaZeus core as a number.
+func (dazeus *DaZeus) GetCoreConfigFloat(key string) (float64, error) {
+	return dazeus.GetCoreConfigFloatContext(context.Background(), key)
+}
+
+// GetCoreConfigFloatContext is like GetCoreConfigFloat, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetCoreConfigFloatContext(ctx context.Context, key string) (float64, error) {
+	return dazeus.GetConfigFloatContext(ctx, key, "core")
+}
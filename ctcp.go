@@ -0,0 +1,127 @@
+package dazeus
+
+import "strings"
+
+const (
+	// ctcpDelimiter marks the start and end of a CTCP message
+	ctcpDelimiter = "\x01"
+	// lowQuote is the escape character of the low-level quoting
+	lowQuote = '\x10'
+	// ctcpQuote is the escape character of the CTCP-level quoting
+	ctcpQuote = '\\'
+)
+
+// CtcpEncode builds a complete CTCP message from a tag and its data, quoting special characters in the data
+func CtcpEncode(tag string, data string) string {
+	message := tag
+	if data != "" {
+		message += " " + data
+	}
+
+	return quoteLow(ctcpDelimiter + quoteCtcp(message) + ctcpDelimiter)
+}
+
+// CtcpDecode splits a CTCP message into its tag and data, removing the quoting of special characters. The CTCP
+// delimiters around the message are optional.
+func CtcpDecode(message string) (tag string, data string) {
+	message = dequoteLow(message)
+	message = strings.TrimPrefix(message, ctcpDelimiter)
+	message = strings.TrimSuffix(message, ctcpDelimiter)
+	message = dequoteCtcp(message)
+
+	tag, data, _ = strings.Cut(message, " ")
+	return tag, data
+}
+
+// quoteCtcpPayload quotes the special characters of a CTCP message without adding the delimiters
+func quoteCtcpPayload(message string) string {
+	return quoteLow(quoteCtcp(message))
+}
+
+// dequoteCtcpPayload removes the quoting of the special characters of a CTCP message
+func dequoteCtcpPayload(message string) string {
+	return dequoteCtcp(dequoteLow(message))
+}
+
+// quoteLow applies the low-level quoting to characters that can not be sent over IRC
+func quoteLow(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\x00':
+			b.WriteString("\x100")
+		case '\n':
+			b.WriteString("\x10n")
+		case '\r':
+			b.WriteString("\x10r")
+		case lowQuote:
+			b.WriteString("\x10\x10")
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// dequoteLow removes the low-level quoting
+func dequoteLow(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != lowQuote || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case '0':
+			b.WriteByte('\x00')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// quoteCtcp applies the CTCP-level quoting to the delimiter and the quote character
+func quoteCtcp(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\x01':
+			b.WriteString(`\a`)
+		case ctcpQuote:
+			b.WriteString(`\\`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+// dequoteCtcp removes the CTCP-level quoting
+func dequoteCtcp(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != ctcpQuote || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		if s[i] == 'a' {
+			b.WriteByte('\x01')
+		} else {
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package dazeus
+
+import "testing"
+
+func TestQuoteLow(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		quoted string
+	}{
+		{"plain", "hello", "hello"},
+		{"quote character", "a\x10b", "a\x10\x10b"},
+		{"NUL", "a\x00b", "a\x100b"},
+		{"CR and LF", "a\r\nb", "a\x10r\x10nb"},
+		{"only specials", "\x10\x00\r\n", "\x10\x10\x100\x10r\x10n"},
+		{"CTCP characters are left alone", "\x01\\", "\x01\\"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if quoted := quoteLow(test.raw); quoted != test.quoted {
+				t.Errorf("quoteLow(%q) = %q, want %q", test.raw, quoted, test.quoted)
+			}
+			if raw := dequoteLow(test.quoted); raw != test.raw {
+				t.Errorf("dequoteLow(%q) = %q, want %q", test.quoted, raw, test.raw)
+			}
+		})
+	}
+}
+
+func TestQuoteCtcp(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		quoted string
+	}{
+		{"plain", "hello", "hello"},
+		{"delimiter", "a\x01b", `a\ab`},
+		{"quote character", `a\b`, `a\\b`},
+		{"quoted a", `\a`, `\\a`},
+		{"only specials", "\x01\\\x01", `\a\\\a`},
+		{"low-level characters are left alone", "\x10\n", "\x10\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if quoted := quoteCtcp(test.raw); quoted != test.quoted {
+				t.Errorf("quoteCtcp(%q) = %q, want %q", test.raw, quoted, test.quoted)
+			}
+			if raw := dequoteCtcp(test.quoted); raw != test.raw {
+				t.Errorf("dequoteCtcp(%q) = %q, want %q", test.quoted, raw, test.raw)
+			}
+		})
+	}
+}
+
+func TestDequoteTrailingEscape(t *testing.T) {
+	if s := dequoteLow("abc\x10"); s != "abc\x10" {
+		t.Errorf("dequoteLow with a trailing escape = %q", s)
+	}
+	if s := dequoteCtcp(`abc\`); s != `abc\` {
+		t.Errorf("dequoteCtcp with a trailing escape = %q", s)
+	}
+	if s := dequoteLow("\x10"); s != "\x10" {
+		t.Errorf("dequoteLow of a lone escape = %q", s)
+	}
+	if s := dequoteCtcp(`\`); s != `\` {
+		t.Errorf("dequoteCtcp of a lone escape = %q", s)
+	}
+}
+
+func TestCtcpRoundTrip(t *testing.T) {
+	tests := []struct {
+		tag  string
+		data string
+	}{
+		{"VERSION", ""},
+		{"ACTION", "waves"},
+		{"PING", "\x10\\\x01\x00\r\n"},
+		{"ACTION", `ends with a backslash \`},
+		{"ACTION", "ends with a quote character \x10"},
+	}
+
+	for _, test := range tests {
+		encoded := CtcpEncode(test.tag, test.data)
+		tag, data := CtcpDecode(encoded)
+		if tag != test.tag || data != test.data {
+			t.Errorf("CtcpDecode(CtcpEncode(%q, %q)) = %q, %q", test.tag, test.data, tag, data)
+		}
+
+		if payload := dequoteCtcpPayload(quoteCtcpPayload(test.data)); payload != test.data {
+			t.Errorf("payload %q round-tripped to %q", test.data, payload)
+		}
+	}
+}
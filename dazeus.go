@@ -2,15 +2,33 @@ package dazeus
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrNotSupported is returned when the core does not support a request
+var ErrNotSupported = errors.New("Request not supported by the core")
+
+// ErrClosed is returned when the connection was closed before or during a request
+var ErrClosed = errors.New("Connection to the core is closed")
+
+// ErrNotAuthenticated is returned when the bot is not authenticated to services on a network
+var ErrNotAuthenticated = errors.New("Not authenticated on the network")
+
 // Message is a message as send by or received from the core.
 type Message map[string]interface{}
 
@@ -19,39 +37,143 @@ type listener struct {
 	event   eventType
 	command string
 	handler Handler
+	scope   Scope
+	// pattern indicates the command is a wildcard pattern instead of a command name
+	pattern bool
+	// commands are the command names registered with the core for the listener
+	commands []string
+	// once indicates the listener is removed after handling a single event
+	once bool
+}
+
+// matches indicates if the listener handles the event
+func (l listener) matches(mapping CaseMapping, evt Event) bool {
+	return l.event == evt.Event && (l.event != EventCommand || l.matchesCommand(mapping, evt.Command))
+}
+
+// matchesCommand indicates if the listener handles the given command
+func (l listener) matchesCommand(mapping CaseMapping, command string) bool {
+	if l.pattern {
+		return wildcardMatch(mapping.Fold(l.command), mapping.Fold(command))
+	}
+
+	return mapping.Equal(l.command, command)
 }
 
 // Handler defines the function type for registering a callback
 type Handler func(Event)
 
+// HistoryLine is a message from the channel history stored by the core
+type HistoryLine struct {
+	Sender  string
+	Message string
+	Time    time.Time
+}
+
+// PluginInfo describes a plugin connected to the core
+type PluginInfo struct {
+	Name    string
+	Version string
+}
+
 // ListenerHandle is used to register and unregister event callbacks
 type ListenerHandle int
 
 // DaZeus contains the connection information for a connection to the dazeus core
 type DaZeus struct {
-	conn          net.Conn
-	buffer        bytes.Buffer
-	listeners     map[ListenerHandle]listener
-	lastHandle    ListenerHandle
-	logger        *log.Logger
-	callDepth     int
-	responseQueue []Message
+	connection          atomic.Pointer[connection]
+	connMutex           sync.Mutex
+	writeMutex          sync.Mutex
+	incoming            *incomingQueue
+	lastError           error
+	dial                func() (net.Conn, error)
+	closed              bool
+	done                chan struct{}
+	doneOnce            sync.Once
+	reconnect           bool
+	maxReconnects       int
+	onReconnect         func(*DaZeus)
+	reconnectMutex      sync.Mutex
+	reconnecting        atomic.Bool
+	buffer              bytes.Buffer
+	listenersMutex      sync.RWMutex
+	listeners           map[ListenerHandle]listener
+	lastHandle          ListenerHandle
+	logger              *log.Logger
+	logOutput           io.Writer
+	logFlags            int
+	logFile             io.Closer
+	pluginName          string
+	handshake           *handshake
+	namespace           string
+	namespaceProperties bool
+	lastPing            time.Time
+	limiter             *rateLimiter
+	targetLimiters      *targetLimiters
+	commandCase         CaseMapping
+	commandPrefix       *string
+	commandPrefixes     []string
+	bufferRetention     int
+	maxMessageSize      int
+	maxLineLength       int
+	isSuccess           func(Message) bool
+	statusNetwork       string
+	statusChannel       string
+	reportingStatus     atomic.Bool
+	internalEvents      map[eventType]bool
+	waitersMutex        sync.Mutex
+	waiters             []*eventWaiter
+	droppedEvents       atomic.Uint64
+	trackState          bool
+	cacheMutex          sync.Mutex
+	state               map[string]*networkState
+	statusPrefixes      map[string]string
+	ignoreMutex         sync.Mutex
+	ignores             map[string][]string
+	incomingHook        func(*Event) bool
+	propertyMutex       sync.Mutex
+	casUnsupported      atomic.Bool
+	handlerTimeout      time.Duration
+	abandonHandlers     bool
+	onPanic             func(Event, interface{})
+	stopOnHandlerError  bool
+	skipMalformed       bool
+	skippedFrames       atomic.Uint64
+	settingsMutex       sync.Mutex
+	replyDefaults       map[string]ReplyDefaults
+	replyTargetResolver func(Event) (string, string)
+	pasteBackend        PasteBackend
+	auditSink           AuditSink
+	mutes               map[string]bool
+	duplicateWindow     time.Duration
+	lastSent            map[string]sentMessage
+	checkOperations     bool
+	allowedOperations   map[string]bool
+	logUnknownEvents    bool
+	unknownEvents       map[eventType]bool
+	stats               connStats
+	clock               Clock
+	runningHandlers     atomic.Int32
 }
 
-// Connect creates a new connection to a DaZeus core with logging to a Discard logger
-func Connect(connectionString string) (*DaZeus, error) {
-	logger := log.New(ioutil.Discard, "[dazeus-go] ", 0)
-	return ConnectWithLogger(connectionString, logger)
+// Connect creates a new connection to a DaZeus core with logging to a Discard logger. The connection string is
+// either tcp:host:port, unix:/path/to/socket or tls:host:port for TCP secured with TLS.
+func Connect(connectionString string, options ...Option) (*DaZeus, error) {
+	return connect(connectionString, nil, options)
 }
 
-// ConnectWithLoggingToStdErr creates a new connection and sets up basic logging to stderr
-func ConnectWithLoggingToStdErr(connectionString string) (*DaZeus, error) {
-	logger := log.New(os.Stderr, "[dazeus-go] ", log.LstdFlags)
-	return ConnectWithLogger(connectionString, logger)
+// ConnectWithTLSConfig creates a new connection secured with TLS using the given configuration, for example to pin
+// the certificate of the core or to set the server name. The connection string must be of the form tls:host:port.
+func ConnectWithTLSConfig(connectionString string, config *tls.Config, options ...Option) (*DaZeus, error) {
+	if !strings.HasPrefix(connectionString, "tls:") {
+		return nil, errors.New("TLS configuration requires a tls connection string")
+	}
+
+	return connect(connectionString, config, options)
 }
 
-// ConnectWithLogger creates a new connection to a DaZeus core with the specified logging instance
-func ConnectWithLogger(connectionString string, logger *log.Logger) (*DaZeus, error) {
+// connect parses the connection string and creates the connection, using the TLS configuration for tls connections
+func connect(connectionString string, config *tls.Config, options []Option) (*DaZeus, error) {
 	parts := strings.SplitN(connectionString, ":", 2)
 	if len(parts) != 2 {
 		return nil, errors.New("Invalid connection string")
@@ -60,112 +182,548 @@ func ConnectWithLogger(connectionString string, logger *log.Logger) (*DaZeus, er
 	format := parts[0]
 	address := parts[1]
 
-	if format != "tcp" && format != "unix" {
+	var dial func() (net.Conn, error)
+	switch format {
+	case "tcp", "unix":
+		dial = func() (net.Conn, error) {
+			return net.Dial(format, address)
+		}
+	case "tls":
+		dial = func() (net.Conn, error) {
+			return tls.Dial("tcp", address, config)
+		}
+	default:
 		return nil, errors.New("No such connection format")
 	}
 
-	conn, err := net.Dial(format, address)
+	conn, err := dial()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dazeus := newDaZeus(conn, dial, options)
+
+	err = dazeus.sendHandshake()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if dazeus.checkOperations {
+		err = dazeus.fetchAllowedOperations()
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if dazeus.trackState {
+		err = dazeus.startTracking()
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return dazeus, nil
+}
+
+// NewWithConn creates a client on a connection to a DaZeus core that was already established, such as one end of
+// a net.Pipe in tests or a custom transport. A nil logger discards the log. The connection can not be
+// re-established when it is lost.
+func NewWithConn(conn net.Conn, logger *log.Logger) *DaZeus {
+	options := []Option{}
+	if logger != nil {
+		options = append(options, WithLogger(logger))
+	}
+
+	return newDaZeus(conn, nil, options)
+}
+
+// newDaZeus sets up a client on an established connection and starts reading from it, dial is used to reconnect
+func newDaZeus(conn net.Conn, dial func() (net.Conn, error), options []Option) *DaZeus {
+	dazeus := &DaZeus{
+		dial:            dial,
+		buffer:          bytes.Buffer{},
+		listeners:       make(map[ListenerHandle]listener, 0),
+		internalEvents:  make(map[eventType]bool),
+		lastHandle:      1,
+		bufferRetention: defaultBufferRetention,
+		maxMessageSize:  DefaultMaxMessageSize,
+		maxLineLength:   defaultLineLength,
+		logOutput:       ioutil.Discard,
+		clock:           realClock{},
+		incoming:        newIncomingQueue(),
+		done:            make(chan struct{}),
+	}
+	dazeus.connection.Store(newConnection(conn))
+
+	for _, option := range options {
+		option(dazeus)
+	}
+
+	dazeus.stats.started = dazeus.clock.Now()
+
+	if dazeus.logger == nil {
+		prefix := "[dazeus-go] "
+		if dazeus.pluginName != "" {
+			prefix = "[" + dazeus.pluginName + "] "
+		}
+		dazeus.logger = log.New(dazeus.logOutput, prefix, dazeus.logFlags)
+	}
+
+	go dazeus.readLoop(dazeus.connection.Load())
+
+	return dazeus
+}
+
+// ConnectWithLoggingToStdErr creates a new connection and sets up basic logging to stderr
+func ConnectWithLoggingToStdErr(connectionString string, options ...Option) (*DaZeus, error) {
+	return Connect(connectionString, append([]Option{withLogOutput(os.Stderr, log.LstdFlags)}, options...)...)
+}
+
+// ConnectWithLogger creates a new connection to a DaZeus core with the specified logging instance
+func ConnectWithLogger(connectionString string, logger *log.Logger, options ...Option) (*DaZeus, error) {
+	return Connect(connectionString, append([]Option{WithLogger(logger)}, options...)...)
+}
+
+// ConnectWithReconnect creates a new connection that is re-established when it is lost, restoring all
+// subscriptions, see WithReconnect. The handler is called after every reconnect, to fetch again any state the plugin
+// keeps about the core, it may be nil.
+func ConnectWithReconnect(connectionString string, maxAttempts int, handler func(*DaZeus), options ...Option) (*DaZeus, error) {
+	return Connect(connectionString, append([]Option{WithReconnect(maxAttempts), WithReconnectHandler(handler)}, options...)...)
+}
+
+// ConnectWithLogFile creates a new connection that logs to the file at the given path, appending to it if it exists.
+// The file is closed when the connection is closed.
+func ConnectWithLogFile(connectionString string, path string, options ...Option) (*DaZeus, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
 
+	dazeus, err := Connect(connectionString, append([]Option{withLogOutput(file, log.LstdFlags)}, options...)...)
 	if err != nil {
+		file.Close()
 		return nil, err
 	}
 
-	return &DaZeus{
-		conn:          conn,
-		buffer:        bytes.Buffer{},
-		listeners:     make(map[ListenerHandle]listener, 0),
-		lastHandle:    1,
-		logger:        logger,
-		callDepth:     0,
-		responseQueue: make([]Message, 0),
-	}, nil
+	dazeus.logFile = file
+	return dazeus, nil
+}
+
+// handshake is how the plugin identifies itself to the core
+type handshake struct {
+	name    string
+	version string
+}
+
+// sendHandshake identifies the plugin to the core, if a handshake was configured
+func (dazeus *DaZeus) sendHandshake() error {
+	if dazeus.handshake == nil {
+		return nil
+	}
+
+	dazeus.logger.Printf("Identifying to the core as %s %s", dazeus.handshake.name, dazeus.handshake.version)
+	_, err := writeForSuccessResponse(context.Background(), dazeus, map[string]interface{}{
+		"do":     "hello",
+		"params": []string{dazeus.handshake.name, dazeus.handshake.version},
+	})
+
+	return err
+}
+
+// PluginName returns the name the plugin identifies itself with, or an empty string if none was set.
+func (dazeus *DaZeus) PluginName() string {
+	return dazeus.pluginName
 }
 
 // Listen starts listening for incoming events, this call is blockin
 func (dazeus *DaZeus) Listen() error {
+	return dazeus.ListenContext(context.Background())
+}
+
+// ListenContext starts listening for incoming events until the context is done, this call is blocking.
+// When reconnecting is enabled a lost connection is re-established, if that fails too often ErrReconnectExhausted
+// is returned. With WithStopOnHandlerError the error of a failing handler is returned. After Close, ErrClosed is
+// returned. Events are received in the background and queued until they are handled here, so requests can be made
+// from any goroutine, including event handlers, whether the plugin is listening or not.
+func (dazeus *DaZeus) ListenContext(ctx context.Context) error {
 	for {
-		err := waitForEvent(dazeus)
+		item, err := dazeus.incoming.pop(ctx)
+		if err != nil {
+			return err
+		}
+
+		if item.err == nil {
+			dazeus.dispatchEvent(item.event)
+			continue
+		}
+
+		err = item.err
+
+		var handlerErr *handlerError
+		if errors.As(err, &handlerErr) {
+			dazeus.reportStatus("Stopped listening for events after a handler failed: %v", handlerErr.err)
+			return handlerErr.err
+		}
+
+		dazeus.recordError(err)
+
+		var frameErr *frameError
+		if dazeus.skipMalformed && errors.As(err, &frameErr) {
+			dazeus.skippedFrames.Add(1)
+			dazeus.logger.Printf("Skipping malformed message: %v", err)
+			continue
+		}
+
+		// the connection fails with a transport error when it was closed on purpose
+		if dazeus.isClosed() {
+			return ErrClosed
+		}
+
+		if !dazeus.reconnect {
+			dazeus.reportStatus("Stopped listening for events: %v", err)
+			return err
+		}
+
+		err = dazeus.reestablish(ctx, err, item.connection)
+		if dazeus.isClosed() {
+			return ErrClosed
+		}
 		if err != nil {
 			return err
 		}
 	}
 }
 
-// Close closes the connection
+// Close closes the connection, closing an already closed connection does nothing. Requests still waiting for the
+// core return ErrClosed.
 func (dazeus *DaZeus) Close() error {
-	dazeus.buffer.Reset()
-	return dazeus.conn.Close()
+	dazeus.connMutex.Lock()
+	if dazeus.closed {
+		dazeus.connMutex.Unlock()
+		return nil
+	}
+	dazeus.closed = true
+	dazeus.connMutex.Unlock()
+
+	dazeus.cancelWaiters()
+	dazeus.terminate()
+
+	err := dazeus.connection.Load().conn.Close()
+
+	if dazeus.logFile != nil {
+		dazeus.logFile.Close()
+	}
+
+	return err
+}
+
+// isClosed indicates if the connection was closed by calling Close
+func (dazeus *DaZeus) isClosed() bool {
+	dazeus.connMutex.Lock()
+	defer dazeus.connMutex.Unlock()
+
+	return dazeus.closed
+}
+
+// IsConnected indicates if the connection to the core is up. While reconnecting it is false, but Done is not closed.
+func (dazeus *DaZeus) IsConnected() bool {
+	select {
+	case <-dazeus.done:
+		return false
+	case <-dazeus.connection.Load().done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Done returns a channel that is closed when the client is terminated, because it was closed or because the
+// connection was lost and could not be re-established.
+func (dazeus *DaZeus) Done() <-chan struct{} {
+	return dazeus.done
+}
+
+// terminate signals that the client will not be connected to the core again
+func (dazeus *DaZeus) terminate() {
+	dazeus.doneOnce.Do(func() {
+		close(dazeus.done)
+	})
+}
+
+// LastError returns the most recent error of a request or of reading from or writing to the core, or nil if
+// there was none since the connection was made or the error was cleared.
+func (dazeus *DaZeus) LastError() error {
+	dazeus.connMutex.Lock()
+	defer dazeus.connMutex.Unlock()
+
+	return dazeus.lastError
+}
+
+// ClearError forgets the most recent error
+func (dazeus *DaZeus) ClearError() {
+	dazeus.recordError(nil)
+}
+
+// recordError remembers the most recent error
+func (dazeus *DaZeus) recordError(err error) {
+	dazeus.connMutex.Lock()
+	defer dazeus.connMutex.Unlock()
+
+	dazeus.lastError = err
+}
+
+// SkippedFrames returns the number of malformed messages skipped while listening
+func (dazeus *DaZeus) SkippedFrames() uint64 {
+	return dazeus.skippedFrames.Load()
 }
 
-// Subscribe registers a handle to receive events
+// LastPingAt returns when the core last sent a keepalive ping, or the zero time if it never did.
+func (dazeus *DaZeus) LastPingAt() time.Time {
+	dazeus.connMutex.Lock()
+	defer dazeus.connMutex.Unlock()
+
+	return dazeus.lastPing
+}
+
+// SetStatusChannel makes the library post notices about significant errors to a channel, so operators can see them
+// without reading the logs. Passing an empty network or channel stops posting notices, which is the default.
+func (dazeus *DaZeus) SetStatusChannel(network string, channel string) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	dazeus.statusNetwork = network
+	dazeus.statusChannel = channel
+}
+
+// reportStatus logs a significant event and posts a notice about it to the status channel, if one is configured
+func (dazeus *DaZeus) reportStatus(format string, v ...interface{}) {
+	message := fmt.Sprintf(format, v...)
+	dazeus.logger.Print(message)
+
+	dazeus.settingsMutex.Lock()
+	network, channel := dazeus.statusNetwork, dazeus.statusChannel
+	dazeus.settingsMutex.Unlock()
+
+	// prevent a failing notice from being reported again
+	if network == "" || channel == "" || !dazeus.reportingStatus.CompareAndSwap(false, true) {
+		return
+	}
+	defer dazeus.reportingStatus.Store(false)
+
+	err := dazeus.Notice(network, channel, message)
+	if err != nil {
+		dazeus.logger.Printf("Could not post status notice: %v", err)
+	}
+}
+
+// SetIncomingHook sets a function that is called for every incoming event before it is passed to the event
+// handlers. The hook may modify the event, or drop it by returning false. Passing nil removes the hook.
+func (dazeus *DaZeus) SetIncomingHook(hook func(*Event) bool) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	dazeus.incomingHook = hook
+}
+
+// getIncomingHook returns the incoming hook, nil when none is set
+func (dazeus *DaZeus) getIncomingHook() func(*Event) bool {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	return dazeus.incomingHook
+}
+
+// Subscribe registers a handle to receive events, the event type must be one of the known event types
 func (dazeus *DaZeus) Subscribe(event eventType, handler Handler) (ListenerHandle, error) {
-	ldata := listener{event, "", handler}
+	return dazeus.SubscribeContext(context.Background(), event, handler)
+}
 
-	dazeus.logger.Printf("Requesting core subscription for events of type '%s'", event)
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+// SubscribeContext is like Subscribe, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SubscribeContext(ctx context.Context, event eventType, handler Handler) (ListenerHandle, error) {
+	if event == EventCommand {
+		return -1, errors.New("Use SubscribeCommand to subscribe to commands")
+	}
+
+	if !isKnownEventType(event) {
+		return -1, fmt.Errorf("Unknown event type '%s', use SubscribeRaw for event types not known to the library", event)
+	}
+
+	return dazeus.SubscribeRawContext(ctx, event, handler)
+}
+
+// SubscribeRaw registers a handle to receive events of any type, including types not known to the library
+func (dazeus *DaZeus) SubscribeRaw(event eventType, handler Handler) (ListenerHandle, error) {
+	return dazeus.SubscribeRawContext(context.Background(), event, handler)
+}
+
+// SubscribeRawContext is like SubscribeRaw, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SubscribeRawContext(ctx context.Context, event eventType, handler Handler) (ListenerHandle, error) {
+	return dazeus.subscribeListener(ctx, listener{event: event, handler: handler, scope: NewUniversalScope()})
+}
+
+// subscribeListener requests the core subscription for the event type of a listener and registers the listener
+func (dazeus *DaZeus) subscribeListener(ctx context.Context, ldata listener) (ListenerHandle, error) {
+	dazeus.logger.Printf("Requesting core subscription for events of type '%s'", ldata.event)
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"do":     "subscribe",
-		"params": []string{string(event)},
+		"params": []string{string(ldata.event)},
 	})
 
 	if err != nil {
 		return -1, err
 	}
 
-	handle := dazeus.lastHandle
-	dazeus.lastHandle++
-	dazeus.listeners[handle] = ldata
-
-	return handle, nil
+	return dazeus.addListener(ldata), nil
 }
 
 // SubscribeCommand allows the user to subscribe to a command
 func (dazeus *DaZeus) SubscribeCommand(command string, scope Scope, handler Handler) (ListenerHandle, error) {
-	ldata := listener{EventCommand, command, handler}
+	return dazeus.SubscribeCommandContext(context.Background(), command, scope, handler)
+}
 
-	scopeSlice, err := scope.ToCommandSlice()
+// SubscribeCommandContext is like SubscribeCommand, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SubscribeCommandContext(ctx context.Context, command string, scope Scope, handler Handler) (ListenerHandle, error) {
+	ldata := listener{event: EventCommand, command: command, handler: handler, scope: scope, commands: []string{command}}
+
+	err := dazeus.registerCommands(ctx, ldata.commands, scope)
 	if err != nil {
 		return -1, err
 	}
 
-	dazeus.logger.Printf("Requesting core subscription for command '%s'", command)
-	_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "command",
-		"params": append([]interface{}{command}, scopeSlice...),
-	})
+	return dazeus.addListener(ldata), nil
+}
+
+// SubscribeOnce subscribes to an event type like Subscribe, but the handler is only called for the first event,
+// after which the subscription is removed. The subscription can be removed with Unsubscribe before the event arrives.
+func (dazeus *DaZeus) SubscribeOnce(event eventType, handler Handler) (ListenerHandle, error) {
+	return dazeus.SubscribeOnceContext(context.Background(), event, handler)
+}
+
+// SubscribeOnceContext is like SubscribeOnce, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SubscribeOnceContext(ctx context.Context, event eventType, handler Handler) (ListenerHandle, error) {
+	if event == EventCommand {
+		return -1, errors.New("Use SubscribeCommandOnce to subscribe to commands")
+	}
+
+	if !isKnownEventType(event) {
+		return -1, fmt.Errorf("Unknown event type '%s', use SubscribeRaw for event types not known to the library", event)
+	}
+
+	return dazeus.subscribeListener(ctx, listener{event: event, handler: handler, scope: NewUniversalScope(), once: true})
+}
+
+// SubscribeCommandOnce subscribes to a command like SubscribeCommand, but the handler is only called for the first
+// time the command is used, after which the subscription is removed.
+func (dazeus *DaZeus) SubscribeCommandOnce(command string, scope Scope, handler Handler) (ListenerHandle, error) {
+	return dazeus.SubscribeCommandOnceContext(context.Background(), command, scope, handler)
+}
+
+// SubscribeCommandOnceContext is like SubscribeCommandOnce, but gives up waiting for the core when the context is
+// done
+func (dazeus *DaZeus) SubscribeCommandOnceContext(ctx context.Context, command string, scope Scope, handler Handler) (ListenerHandle, error) {
+	ldata := listener{event: EventCommand, command: command, handler: handler, scope: scope, commands: []string{command}, once: true}
 
+	err := dazeus.registerCommands(ctx, ldata.commands, scope)
 	if err != nil {
 		return -1, err
 	}
 
-	handle := dazeus.lastHandle
-	dazeus.lastHandle++
-	dazeus.listeners[handle] = ldata
+	return dazeus.addListener(ldata), nil
+}
 
-	return handle, nil
+// SubscribeCommandPattern subscribes to all commands matching a pattern, where * matches any number of characters
+// and ? matches a single character, such as "admin.*" or "*" for a catch-all. The core only sends the commands that
+// are registered with it, so the pattern matches the commands registered by other command subscriptions, and the
+// given commands are registered for this subscription. A catch-all therefore does not receive unregistered commands.
+func (dazeus *DaZeus) SubscribeCommandPattern(pattern string, scope Scope, handler Handler, commands ...string) (ListenerHandle, error) {
+	return dazeus.SubscribeCommandPatternContext(context.Background(), pattern, scope, handler, commands...)
+}
+
+// SubscribeCommandPatternContext is like SubscribeCommandPattern, but gives up waiting for the core when the
+// context is done
+func (dazeus *DaZeus) SubscribeCommandPatternContext(ctx context.Context, pattern string, scope Scope, handler Handler, commands ...string) (ListenerHandle, error) {
+	ldata := listener{event: EventCommand, command: pattern, handler: handler, scope: scope, pattern: true, commands: commands}
+
+	err := dazeus.registerCommands(ctx, commands, scope)
+	if err != nil {
+		return -1, err
+	}
+
+	return dazeus.addListener(ldata), nil
+}
+
+// registerCommands registers commands with the core, so it sends COMMAND events for them
+func (dazeus *DaZeus) registerCommands(ctx context.Context, commands []string, scope Scope) error {
+	scopeSlice, err := scope.ToCommandSlice()
+	if err != nil {
+		return err
+	}
+
+	for _, command := range commands {
+		dazeus.logger.Printf("Requesting core subscription for command '%s'", command)
+		_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+			"do":     "command",
+			"params": append([]interface{}{command}, scopeSlice...),
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // Unsubscribe removes a subscription to a specific kind of event
 func (dazeus *DaZeus) Unsubscribe(handle ListenerHandle) error {
-	listener, ok := dazeus.listeners[handle]
+	return dazeus.UnsubscribeContext(context.Background(), handle)
+}
+
+// UnsubscribeContext is like Unsubscribe, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) UnsubscribeContext(ctx context.Context, handle ListenerHandle) error {
+	listener, found, ok := dazeus.removeListener(handle)
 
 	if !ok {
 		return errors.New("No listener found")
 	}
+
+	return dazeus.releaseListener(ctx, listener, found)
+}
+
+// removeListener removes a listener, found indicates if the core subscription for its event type is still needed
+func (dazeus *DaZeus) removeListener(handle ListenerHandle) (listener listener, found bool, ok bool) {
+	dazeus.listenersMutex.Lock()
+	defer dazeus.listenersMutex.Unlock()
+
+	listener, ok = dazeus.listeners[handle]
+	if !ok {
+		return
+	}
 	delete(dazeus.listeners, handle)
 
+	found = dazeus.internalEvents[listener.event]
+	for _, l := range dazeus.listeners {
+		if l.event == listener.event {
+			found = true
+			break
+		}
+	}
+
+	return
+}
+
+// releaseListener unsubscribes from the core events of a removed listener when no other listener needs them
+func (dazeus *DaZeus) releaseListener(ctx context.Context, listener listener, found bool) error {
 	if listener.event != "COMMAND" {
 		dazeus.logger.Printf("Removed event listener for events of type '%s'", listener.event)
-		found := false
-		for _, l := range dazeus.listeners {
-			if l.event == listener.event {
-				found = true
-				break
-			}
-		}
 
 		if !found {
 			dazeus.logger.Printf("Unsubscribing to core events of type '%s'", listener.event)
-			_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+			_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 				"do":     "unsubscribe",
 				"params": []string{string(listener.event)},
 			})
@@ -179,9 +737,73 @@ func (dazeus *DaZeus) Unsubscribe(handle ListenerHandle) error {
 	return nil
 }
 
+// subscribeInternally makes sure the core sends events of the given type, for use by the library itself.
+// Such subscriptions are kept when all listeners for the event type are removed.
+func (dazeus *DaZeus) subscribeInternally(event eventType) error {
+	dazeus.listenersMutex.Lock()
+	subscribed := dazeus.internalEvents[event]
+	for _, l := range dazeus.listeners {
+		if l.event == event {
+			subscribed = true
+			break
+		}
+	}
+	if subscribed {
+		dazeus.internalEvents[event] = true
+	}
+	dazeus.listenersMutex.Unlock()
+
+	if subscribed {
+		return nil
+	}
+
+	dazeus.logger.Printf("Requesting internal core subscription for events of type '%s'", event)
+	_, err := writeForSuccessResponse(context.Background(), dazeus, map[string]interface{}{
+		"do":     "subscribe",
+		"params": []string{string(event)},
+	})
+	if err != nil {
+		return err
+	}
+
+	dazeus.listenersMutex.Lock()
+	dazeus.internalEvents[event] = true
+	dazeus.listenersMutex.Unlock()
+	return nil
+}
+
+// addListener registers a listener for which the core subscription was made, returning its handle
+func (dazeus *DaZeus) addListener(ldata listener) ListenerHandle {
+	dazeus.listenersMutex.Lock()
+	defer dazeus.listenersMutex.Unlock()
+
+	handle := dazeus.lastHandle
+	dazeus.lastHandle++
+	dazeus.listeners[handle] = ldata
+
+	return handle
+}
+
+// Request sends a request the library has no method for yet, returning the response when it was successful. The
+// caller is responsible for the shape of the message, such as {"get": "networks"} or {"do": "message", "params":
+// [...]}, and for interpreting the response.
+func (dazeus *DaZeus) Request(message Message) (Message, error) {
+	return dazeus.RequestContext(context.Background(), message)
+}
+
+// RequestContext is like Request, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) RequestContext(ctx context.Context, message Message) (Message, error) {
+	return writeForSuccessResponse(ctx, dazeus, message)
+}
+
 // Networks retrieves the networks the DaZeus core is connected to.
 func (dazeus *DaZeus) Networks() ([]string, error) {
-	resp, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+	return dazeus.NetworksContext(context.Background())
+}
+
+// NetworksContext is like Networks, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) NetworksContext(ctx context.Context) ([]string, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"get": "networks",
 	})
 	if err != nil {
@@ -193,7 +815,12 @@ func (dazeus *DaZeus) Networks() ([]string, error) {
 
 // Channels lists the channels to which the bot is connected in the given network.
 func (dazeus *DaZeus) Channels(network string) ([]string, error) {
-	resp, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+	return dazeus.ChannelsContext(context.Background(), network)
+}
+
+// ChannelsContext is like Channels, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ChannelsContext(ctx context.Context, network string) ([]string, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"get":    "channels",
 		"params": []string{network},
 	})
@@ -204,80 +831,480 @@ func (dazeus *DaZeus) Channels(network string) ([]string, error) {
 	return makeStringArray(resp["channels"])
 }
 
-// Join allows the bot to join a specific channel in some network
-func (dazeus *DaZeus) Join(network string, channel string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "join",
-		"params": []string{network, channel},
-	})
-
-	return err
+// History retrieves at most limit recent messages in a channel, if the core keeps a history of that channel.
+// ErrNotSupported is returned when the core does not store history.
+func (dazeus *DaZeus) History(network string, channel string, limit int) ([]HistoryLine, error) {
+	return dazeus.HistoryContext(context.Background(), network, channel, limit)
+}
+
+// HistoryContext is like History, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) HistoryContext(ctx context.Context, network string, channel string, limit int) ([]HistoryLine, error) {
+	resp, err := writeForOptionalResponse(ctx, dazeus, map[string]interface{}{
+		"get":    "history",
+		"params": []string{network, channel, strconv.Itoa(limit)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := resp["history"].([]interface{})
+	if !ok {
+		return nil, errors.New("No history found in response")
+	}
+
+	lines := make([]HistoryLine, 0, len(entries))
+	for _, entry := range entries {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("Found invalid entry in history")
+		}
+
+		sender, _ := fields["sender"].(string)
+		message, _ := fields["message"].(string)
+		timestamp, _ := fields["time"].(float64)
+		lines = append(lines, HistoryLine{
+			Sender:  sender,
+			Message: message,
+			Time:    time.Unix(int64(timestamp), 0),
+		})
+	}
+
+	return lines, nil
+}
+
+// Plugins retrieves the plugins connected to the core. ErrNotSupported is returned when the core can not list them.
+func (dazeus *DaZeus) Plugins() ([]PluginInfo, error) {
+	return dazeus.PluginsContext(context.Background())
+}
+
+// PluginsContext is like Plugins, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) PluginsContext(ctx context.Context) ([]PluginInfo, error) {
+	resp, err := writeForOptionalResponse(ctx, dazeus, map[string]interface{}{
+		"get": "plugins",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := resp["plugins"].([]interface{})
+	if !ok {
+		return nil, errors.New("No plugins found in response")
+	}
+
+	plugins := make([]PluginInfo, 0, len(entries))
+	for _, entry := range entries {
+		fields, ok := entry.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("Found invalid entry in plugin list")
+		}
+
+		name, _ := fields["name"].(string)
+		version, _ := fields["version"].(string)
+		plugins = append(plugins, PluginInfo{
+			Name:    name,
+			Version: version,
+		})
+	}
+
+	return plugins, nil
+}
+
+// Join allows the bot to join a specific channel in some network
+func (dazeus *DaZeus) Join(network string, channel string) error {
+	return dazeus.JoinContext(context.Background(), network, channel)
+}
+
+// JoinContext is like Join, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) JoinContext(ctx context.Context, network string, channel string) error {
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"do":     "join",
+		"params": []string{network, channel},
+	})
+
+	return err
+}
+
+// Part allows the bot to leave a specific channel in some network.
+func (dazeus *DaZeus) Part(network string, channel string) error {
+	return dazeus.PartContext(context.Background(), network, channel)
+}
+
+// PartContext is like Part, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) PartContext(ctx context.Context, network string, channel string) error {
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"do":     "part",
+		"params": []string{network, channel},
+	})
+
+	return err
+}
+
+// Kick removes a user from a channel in some network, the reason is left out when it is empty.
+func (dazeus *DaZeus) Kick(network string, channel string, nick string, reason string) error {
+	return dazeus.KickContext(context.Background(), network, channel, nick, reason)
+}
+
+// KickContext is like Kick, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) KickContext(ctx context.Context, network string, channel string, nick string, reason string) error {
+	params := []string{network, channel, nick}
+	if reason != "" {
+		params = append(params, reason)
+	}
+
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"do":     "kick",
+		"params": params,
+	})
+
+	return err
+}
+
+// Mode changes the modes of a channel in some network, such as Mode(network, channel, "+o", nick).
+func (dazeus *DaZeus) Mode(network string, channel string, modes string, args ...string) error {
+	return dazeus.ModeContext(context.Background(), network, channel, modes, args...)
+}
+
+// ModeContext is like Mode, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ModeContext(ctx context.Context, network string, channel string, modes string, args ...string) error {
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"do":     "mode",
+		"params": append([]string{network, channel, modes}, args...),
+	})
+
+	return err
+}
+
+// Ban bans a mask from a channel in some network, see BanMask for building a mask from a hostmask.
+func (dazeus *DaZeus) Ban(network string, channel string, mask string) error {
+	return dazeus.BanContext(context.Background(), network, channel, mask)
+}
+
+// BanContext is like Ban, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) BanContext(ctx context.Context, network string, channel string, mask string) error {
+	return dazeus.ModeContext(ctx, network, channel, "+b", mask)
+}
+
+// Message sends the given message to some channel in some network. The message is passed to the core as Unicode text,
+// the encoding used on the network is configured in the core.
+func (dazeus *DaZeus) Message(network string, channel string, message string) error {
+	return dazeus.MessageContext(context.Background(), network, channel, message)
+}
+
+// MessageContext is like Message, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) MessageContext(ctx context.Context, network string, channel string, message string) error {
+	return dazeus.send(ctx, "message", network, channel, message)
+}
+
+// MessageLines sends a message to a channel in some network, split into as many messages as needed to stay within
+// the maximum line length. The message is split at newlines and between words, the lines are sent in order.
+func (dazeus *DaZeus) MessageLines(network string, channel string, message string) error {
+	return dazeus.MessageLinesContext(context.Background(), network, channel, message)
+}
+
+// MessageLinesContext is like MessageLines, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) MessageLinesContext(ctx context.Context, network string, channel string, message string) error {
+	for _, line := range splitMessage(message, dazeus.maxLineLength) {
+		err := dazeus.MessageContext(ctx, network, channel, line)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Action sends a CTCP action message to a channel in some network.
+func (dazeus *DaZeus) Action(network string, channel string, message string) error {
+	return dazeus.ActionContext(context.Background(), network, channel, message)
+}
+
+// ActionContext is like Action, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ActionContext(ctx context.Context, network string, channel string, message string) error {
+	return dazeus.send(ctx, "action", network, channel, message)
+}
+
+// Notice sends a notice message to a channel in some network.
+func (dazeus *DaZeus) Notice(network string, channel string, message string) error {
+	return dazeus.NoticeContext(context.Background(), network, channel, message)
+}
+
+// NoticeContext is like Notice, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) NoticeContext(ctx context.Context, network string, channel string, message string) error {
+	return dazeus.send(ctx, "notice", network, channel, message)
+}
+
+// Ctcp sends a CTCP message to a channel in some network, special characters in the message are quoted.
+func (dazeus *DaZeus) Ctcp(network string, channel string, message string) error {
+	return dazeus.CtcpContext(context.Background(), network, channel, message)
+}
+
+// CtcpContext is like Ctcp, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) CtcpContext(ctx context.Context, network string, channel string, message string) error {
+	return dazeus.send(ctx, "ctcp", network, channel, quoteCtcpPayload(message))
+}
+
+// CtcpReply sends a CTCP reply message to a channel in some network, special characters in the message are quoted.
+func (dazeus *DaZeus) CtcpReply(network string, channel string, message string) error {
+	return dazeus.CtcpReplyContext(context.Background(), network, channel, message)
+}
+
+// CtcpReplyContext is like CtcpReply, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) CtcpReplyContext(ctx context.Context, network string, channel string, message string) error {
+	return dazeus.send(ctx, "ctcp_rep", network, channel, quoteCtcpPayload(message))
+}
+
+// Broadcast sends the same message to several channels in some network. The messages are paced by the configured
+// rate limit, or by a conservative default if no rate limit was configured. Failures for individual channels do not
+// stop the broadcast, instead all of them are returned together.
+func (dazeus *DaZeus) Broadcast(network string, channels []string, message string) error {
+	return dazeus.BroadcastContext(context.Background(), network, channels, message)
+}
+
+// BroadcastContext is like Broadcast, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) BroadcastContext(ctx context.Context, network string, channels []string, message string) error {
+	var pacer *rateLimiter
+	if dazeus.limiter == nil {
+		pacer = newRateLimiter(defaultBroadcastInterval, defaultBroadcastBurst)
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		if pacer != nil {
+			err := pacer.wait(ctx, dazeus.clock)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+
+		err := dazeus.MessageContext(ctx, network, channel, message)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Broadcast to %s failed: %w", channel, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		dazeus.reportStatus("Broadcast failed for %d of %d channels", len(errs), len(channels))
+	}
+
+	return errors.Join(errs...)
+}
+
+// send sends a chat message of some kind to a channel in some network, respecting the rate limits. Messages, actions
+// and notices to muted channels are suppressed, just like duplicates when duplicate suppression is enabled.
+func (dazeus *DaZeus) send(ctx context.Context, kind string, network string, channel string, message string) error {
+	muted := kind == "message" || kind == "action" || kind == "notice"
+	if muted && dazeus.IsMuted(network, channel) {
+		dazeus.logger.Printf("Suppressing %s to muted channel %s in %s: %s", kind, channel, network, message)
+		return nil
+	}
+
+	if dazeus.isDuplicate(network, channel, message) {
+		dazeus.logger.Printf("Suppressing duplicate %s to %s in %s: %s", kind, channel, network, message)
+		return nil
+	}
+
+	if dazeus.targetLimiters != nil {
+		err := dazeus.targetLimiters.wait(ctx, dazeus.clock, network, channel)
+		if err != nil {
+			return err
+		}
+	}
+	if dazeus.limiter != nil {
+		err := dazeus.limiter.wait(ctx, dazeus.clock)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"do":     kind,
+		"params": []string{network, channel, message},
+	})
+
+	if err == nil {
+		dazeus.recordSent(network, channel, message)
+	}
+
+	return err
+}
+
+// IsOnline checks which of the given nicks are online in some network. When the core does not support ISON queries
+// every nick is looked up with a whois request instead, which is a lot slower.
+func (dazeus *DaZeus) IsOnline(network string, nicks []string) (map[string]bool, error) {
+	return dazeus.IsOnlineContext(context.Background(), network, nicks)
+}
+
+// IsOnlineContext is like IsOnline, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) IsOnlineContext(ctx context.Context, network string, nicks []string) (map[string]bool, error) {
+	resp, err := writeForOptionalResponse(ctx, dazeus, map[string]interface{}{
+		"get":    "ison",
+		"params": append([]string{network}, nicks...),
+	})
+
+	if err == ErrNotSupported {
+		return dazeus.isOnlineByWhois(ctx, network, nicks)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	online, err := makeStringArray(resp["online"])
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]bool, len(nicks))
+	for _, nick := range nicks {
+		result[nick] = false
+		for _, o := range online {
+			if CaseRFC1459.Equal(nick, o) {
+				result[nick] = true
+				break
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// isOnlineByWhois checks which nicks are online by sending a whois request for every nick.
+func (dazeus *DaZeus) isOnlineByWhois(ctx context.Context, network string, nicks []string) (map[string]bool, error) {
+	result := make(map[string]bool, len(nicks))
+	for _, nick := range nicks {
+		whois, err := dazeus.WhoisSyncContext(ctx, network, nick)
+		if err != nil {
+			return nil, err
+		}
+
+		result[nick] = whois.Online
+	}
+
+	return result, nil
+}
+
+// RTT measures the round-trip time to the IRC server of some network, by sending a PING and waiting for the
+// matching PONG.
+func (dazeus *DaZeus) RTT(network string) (time.Duration, error) {
+	return dazeus.RTTContext(context.Background(), network)
+}
+
+// RTTContext is like RTT, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) RTTContext(ctx context.Context, network string) (time.Duration, error) {
+	err := dazeus.subscribeInternally(EventPong)
+	if err != nil {
+		return 0, err
+	}
+
+	token := "dazeus-go-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	waiter := dazeus.addWaiter(func(evt Event) bool {
+		return evt.Event == EventPong && evt.Network == network &&
+			(evt.Channel == token || (len(evt.Params) > 0 && evt.Params[0] == token))
+	})
+
+	start := dazeus.clock.Now()
+	_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"do":     "ping",
+		"params": []string{network, token},
+	})
+	if err != nil {
+		dazeus.removeWaiter(waiter)
+		return 0, err
+	}
+
+	_, err = waitForWaiter(ctx, dazeus, waiter, defaultWaitTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	return dazeus.clock.Now().Sub(start), nil
+}
+
+// Nick retrieves the nickname for the bot in a specific network.
+func (dazeus *DaZeus) Nick(network string) (string, error) {
+	return dazeus.NickContext(context.Background(), network)
+}
+
+// NickContext is like Nick, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) NickContext(ctx context.Context, network string) (string, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"get":    "nick",
+		"params": []string{network},
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	nick, ok := resp["nick"].(string)
+
+	if !ok {
+		return "", errors.New("No nick found in response")
+	}
+
+	dazeus.logger.Printf("Nick in network %s is %s", network, nick)
+	return nick, nil
+}
+
+// DaZeusVersion retrieves the version of the DaZeus core.
+func (dazeus *DaZeus) DaZeusVersion() (string, error) {
+	return dazeus.DaZeusVersionContext(context.Background())
 }
 
-// Part allows the bot to leave a specific channel in some network.
-func (dazeus *DaZeus) Part(network string, channel string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "part",
-		"params": []string{network, channel},
+// DaZeusVersionContext is like DaZeusVersion, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) DaZeusVersionContext(ctx context.Context) (string, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"get": "dazeus-version",
 	})
 
-	return err
-}
+	if err != nil {
+		return "", err
+	}
 
-// Message sends the given message to some channel in some network.
-func (dazeus *DaZeus) Message(network string, channel string, message string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "message",
-		"params": []string{network, channel, message},
-	})
+	version, ok := resp["version"].(string)
 
-	return err
-}
+	if !ok {
+		return "", errors.New("No version found in response")
+	}
 
-// Action sends a CTCP action message to a channel in some network.
-func (dazeus *DaZeus) Action(network string, channel string, message string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "action",
-		"params": []string{network, channel, message},
-	})
+	return version, nil
+}
 
-	return err
+// BotVersion retrieves the version the bot reports in a specific network, for example in CTCP VERSION replies.
+func (dazeus *DaZeus) BotVersion(network string) (string, error) {
+	return dazeus.BotVersionContext(context.Background(), network)
 }
 
-// Notice sends a notice message to a channel in some network.
-func (dazeus *DaZeus) Notice(network string, channel string, message string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "notice",
-		"params": []string{network, channel, message},
+// BotVersionContext is like BotVersion, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) BotVersionContext(ctx context.Context, network string) (string, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
+		"get":    "bot-version",
+		"params": []string{network},
 	})
 
-	return err
-}
+	if err != nil {
+		return "", err
+	}
 
-// Ctcp sends a CTCP message to a channel in some network.
-func (dazeus *DaZeus) Ctcp(network string, channel string, message string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "ctcp",
-		"params": []string{network, channel, message},
-	})
+	version, ok := resp["version"].(string)
 
-	return err
-}
+	if !ok {
+		return "", errors.New("No version found in response")
+	}
 
-// CtcpReply sends a CTCP reply message to a channel in some network.
-func (dazeus *DaZeus) CtcpReply(network string, channel string, message string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"do":     "ctcp_rep",
-		"params": []string{network, channel, message},
-	})
+	return version, nil
+}
 
-	return err
+// Account retrieves the account name the bot is authenticated with to services on a network. It returns
+// ErrNotAuthenticated when the bot has no account on the network, and ErrNotSupported when the core can not tell.
+func (dazeus *DaZeus) Account(network string) (string, error) {
+	return dazeus.AccountContext(context.Background(), network)
 }
 
-// Nick retrieves the nickname for the bot in a specific network.
-func (dazeus *DaZeus) Nick(network string) (string, error) {
-	resp, err := writeForSuccessResponse(dazeus, map[string]interface{}{
-		"get":    "nick",
+// AccountContext is like Account, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) AccountContext(ctx context.Context, network string) (string, error) {
+	resp, err := writeForOptionalResponse(ctx, dazeus, map[string]interface{}{
+		"get":    "account",
 		"params": []string{network},
 	})
 
@@ -285,20 +1312,31 @@ func (dazeus *DaZeus) Nick(network string) (string, error) {
 		return "", err
 	}
 
-	fmt.Printf("Nicks resp %#v", resp)
+	if resp["account"] == nil {
+		return "", ErrNotAuthenticated
+	}
 
-	nick, ok := resp["nick"].(string)
+	account, ok := resp["account"].(string)
 
 	if !ok {
-		return "", errors.New("No nick found in response")
+		return "", errors.New("No account found in response")
 	}
 
-	return nick, nil
+	if account == "" {
+		return "", ErrNotAuthenticated
+	}
+
+	return account, nil
 }
 
 // GetConfig retrieves a config value.
 func (dazeus *DaZeus) GetConfig(key string, group string) (string, error) {
-	resp, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+	return dazeus.GetConfigContext(context.Background(), key, group)
+}
+
+// GetConfigContext is like GetConfig, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetConfigContext(ctx context.Context, key string, group string) (string, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"get":    "config",
 		"params": []string{group, key},
 	})
@@ -316,82 +1354,383 @@ func (dazeus *DaZeus) GetConfig(key string, group string) (string, error) {
 	return value, nil
 }
 
+// GetConfigs retrieves several config values of the same group at once. The requests are sent together, so this
+// is faster than retrieving them one by one. When some values could not be retrieved the others are still returned,
+// together with the errors for the failed ones.
+func (dazeus *DaZeus) GetConfigs(group string, keys []string) (map[string]string, error) {
+	return dazeus.GetConfigsContext(context.Background(), group, keys)
+}
+
+// GetConfigsContext is like GetConfigs, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetConfigsContext(ctx context.Context, group string, keys []string) (map[string]string, error) {
+	requests := make([]Message, len(keys))
+	for i, key := range keys {
+		requests[i] = map[string]interface{}{
+			"get":    "config",
+			"params": []string{group, key},
+		}
+	}
+
+	responses, errs, err := writeForSuccessResponses(ctx, dazeus, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(keys))
+	var failed []error
+	for i, key := range keys {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Errorf("Config %s failed: %w", key, errs[i]))
+			continue
+		}
+
+		value, ok := responses[i]["value"].(string)
+		if !ok {
+			failed = append(failed, fmt.Errorf("Config %s failed: No value found in response", key))
+			continue
+		}
+
+		values[key] = value
+	}
+
+	return values, errors.Join(failed...)
+}
+
 // GetPluginConfig gets a config value for the plugin from the DaZeus core.
 func (dazeus *DaZeus) GetPluginConfig(key string) (string, error) {
-	return dazeus.GetConfig(key, "plugin")
+	return dazeus.GetPluginConfigContext(context.Background(), key)
+}
+
+// GetPluginConfigContext is like GetPluginConfig, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetPluginConfigContext(ctx context.Context, key string) (string, error) {
+	return dazeus.GetConfigContext(ctx, key, "plugin")
 }
 
 // GetCoreConfig gets a config value for the DaZeus core.
 func (dazeus *DaZeus) GetCoreConfig(key string) (string, error) {
-	return dazeus.GetConfig(key, "core")
+	return dazeus.GetCoreConfigContext(context.Background(), key)
+}
+
+// GetCoreConfigContext is like GetCoreConfig, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetCoreConfigContext(ctx context.Context, key string) (string, error) {
+	return dazeus.GetConfigContext(ctx, key, "core")
 }
 
 // HighlightCharacter gets the character used for highlighting the bot.
 func (dazeus *DaZeus) HighlightCharacter() (string, error) {
-	return dazeus.GetCoreConfig("highlight")
+	return dazeus.HighlightCharacterContext(context.Background())
+}
+
+// HighlightCharacterContext is like HighlightCharacter, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) HighlightCharacterContext(ctx context.Context) (string, error) {
+	return dazeus.GetCoreConfigContext(ctx, "highlight")
+}
+
+// CommandPrefix gets the prefix that marks a message as a command, which is the highlight character of the core.
+// The prefix is retrieved from the core only once.
+func (dazeus *DaZeus) CommandPrefix() (string, error) {
+	return dazeus.CommandPrefixContext(context.Background())
+}
+
+// CommandPrefixContext is like CommandPrefix, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) CommandPrefixContext(ctx context.Context) (string, error) {
+	dazeus.settingsMutex.Lock()
+	cached := dazeus.commandPrefix
+	dazeus.settingsMutex.Unlock()
+
+	if cached != nil {
+		return *cached, nil
+	}
+
+	prefix, err := dazeus.HighlightCharacterContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	dazeus.settingsMutex.Lock()
+	dazeus.commandPrefix = &prefix
+	dazeus.settingsMutex.Unlock()
+	return prefix, nil
+}
+
+// CommandPrefixes gets all prefixes that mark a message as a command. When the core can not list them, the only
+// prefix is the highlight character. The prefixes are retrieved from the core only once.
+func (dazeus *DaZeus) CommandPrefixes() ([]string, error) {
+	return dazeus.CommandPrefixesContext(context.Background())
+}
+
+// CommandPrefixesContext is like CommandPrefixes, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) CommandPrefixesContext(ctx context.Context) ([]string, error) {
+	dazeus.settingsMutex.Lock()
+	cached := dazeus.commandPrefixes
+	dazeus.settingsMutex.Unlock()
+
+	if cached != nil {
+		return cached, nil
+	}
+
+	var prefixes []string
+	resp, err := writeForOptionalResponse(ctx, dazeus, map[string]interface{}{
+		"get": "command_prefixes",
+	})
+	if err == nil {
+		prefixes, err = makeStringArray(resp["prefixes"])
+	} else if err == ErrNotSupported {
+		var prefix string
+		prefix, err = dazeus.CommandPrefixContext(ctx)
+		prefixes = []string{prefix}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// check longer prefixes first, so they win over prefixes they start with
+	sort.SliceStable(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	dazeus.settingsMutex.Lock()
+	dazeus.commandPrefixes = prefixes
+	dazeus.settingsMutex.Unlock()
+	return prefixes, nil
+}
+
+// StripCommandPrefix removes the command prefix from a message, and indicates whether the message started with one
+// of the command prefixes.
+func (dazeus *DaZeus) StripCommandPrefix(s string) (string, bool) {
+	prefixes, err := dazeus.CommandPrefixes()
+	if err != nil {
+		return s, false
+	}
+
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(s, prefix) {
+			return strings.TrimPrefix(s, prefix), true
+		}
+	}
+
+	return s, false
 }
 
 // GetProperty retrieves a property for a given scope.
 func (dazeus *DaZeus) GetProperty(property string, scope Scope) (interface{}, error) {
-	var err error
+	return dazeus.GetPropertyContext(context.Background(), property, scope)
+}
+
+// GetPropertyContext is like GetProperty, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetPropertyContext(ctx context.Context, property string, scope Scope) (interface{}, error) {
+	value, found, err := dazeus.lookupProperty(ctx, property, scope)
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		return "", errors.New("No value found in response")
+	}
+
+	return value, nil
+}
+
+// lookupProperty retrieves a property like GetProperty, found is false when the property is not set
+func (dazeus *DaZeus) lookupProperty(ctx context.Context, property string, scope Scope) (value interface{}, found bool, err error) {
 	var resp map[string]interface{}
 
 	if scope.IsAll() {
-		resp, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		resp, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
-			"params": []string{"get", property},
+			"params": []string{"get", dazeus.propertyKey(property)},
 		})
 	} else {
-		resp, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		resp, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
 			"scope":  scope.ToSlice(),
-			"params": []string{"get", property},
+			"params": []string{"get", dazeus.propertyKey(property)},
 		})
 	}
 
 	if err != nil {
-		return "", err
-	}
-
-	value, ok := resp["value"].(interface{})
-
-	if !ok {
-		return "", errors.New("No value found in response")
+		return nil, false, err
 	}
 
-	return value, nil
+	value = resp["value"]
+	return value, value != nil, nil
 }
 
 // SetProperty sets a property to a string value for a given Scope.
 func (dazeus *DaZeus) SetProperty(property string, value interface{}, scope Scope) (err error) {
+	return dazeus.SetPropertyContext(context.Background(), property, value, scope)
+}
+
+// SetPropertyContext is like SetProperty, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SetPropertyContext(ctx context.Context, property string, value interface{}, scope Scope) (err error) {
 	if scope.IsAll() {
-		_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
-			"params": []interface{}{"set", property, value},
+			"params": []interface{}{"set", dazeus.propertyKey(property), value},
 		})
 	} else {
-		_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
 			"scope":  scope.ToSlice(),
-			"params": []interface{}{"set", property, value},
+			"params": []interface{}{"set", dazeus.propertyKey(property), value},
 		})
 	}
 
 	return
 }
 
+// ErrInvalidPropertyJSON is returned when a property does not hold JSON for the requested type
+var ErrInvalidPropertyJSON = errors.New("Property does not contain valid JSON")
+
+// SetPropertyJSON stores a value encoded as JSON in a property, to be retrieved with GetPropertyJSON.
+func (dazeus *DaZeus) SetPropertyJSON(property string, value interface{}, scope Scope) error {
+	return dazeus.SetPropertyJSONContext(context.Background(), property, value, scope)
+}
+
+// SetPropertyJSONContext is like SetPropertyJSON, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SetPropertyJSONContext(ctx context.Context, property string, value interface{}, scope Scope) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return dazeus.SetPropertyContext(ctx, property, string(encoded), scope)
+}
+
+// GetPropertyJSON decodes the JSON stored in a property into out, which is left untouched when the property is not
+// set. It returns an error wrapping ErrInvalidPropertyJSON when the value can not be decoded into out.
+func (dazeus *DaZeus) GetPropertyJSON(property string, out interface{}, scope Scope) error {
+	return dazeus.GetPropertyJSONContext(context.Background(), property, out, scope)
+}
+
+// GetPropertyJSONContext is like GetPropertyJSON, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) GetPropertyJSONContext(ctx context.Context, property string, out interface{}, scope Scope) error {
+	value, err := dazeus.GetPropertyContext(ctx, property, scope)
+	if err != nil {
+		return err
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	// the core may hand back the value decoded already, encode it again to decode it into out
+	encoded, ok := value.(string)
+	if !ok {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPropertyJSON, err)
+		}
+		encoded = string(raw)
+	}
+
+	err = json.Unmarshal([]byte(encoded), out)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPropertyJSON, err)
+	}
+
+	return nil
+}
+
+// CompareAndSwapProperty sets a property to a new value only if its current value equals old, an unset property
+// has the empty string as value and a property that is not a string is never swapped. It reports whether the
+// property was set. When the core can not do this atomically, the library gets and sets the property itself, which
+// is only atomic with respect to other compare-and-swap calls on the same connection.
+func (dazeus *DaZeus) CompareAndSwapProperty(property string, old string, new string, scope Scope) (bool, error) {
+	return dazeus.CompareAndSwapPropertyContext(context.Background(), property, old, new, scope)
+}
+
+// CompareAndSwapPropertyContext is like CompareAndSwapProperty, but gives up waiting when the context is done
+func (dazeus *DaZeus) CompareAndSwapPropertyContext(ctx context.Context, property string, old string, new string, scope Scope) (bool, error) {
+	if !dazeus.casUnsupported.Load() {
+		request := map[string]interface{}{
+			"do":     "property",
+			"params": []string{"cas", dazeus.propertyKey(property), old, new},
+		}
+		if !scope.IsAll() {
+			request["scope"] = scope.ToSlice()
+		}
+
+		resp, err := writeForOptionalResponse(ctx, dazeus, request)
+		if err == nil {
+			swapped, ok := resp["swapped"].(bool)
+			if !ok {
+				return false, errors.New("No swap result found in response")
+			}
+			return swapped, nil
+		}
+		if err != ErrNotSupported {
+			return false, err
+		}
+
+		dazeus.casUnsupported.Store(true)
+	}
+
+	dazeus.propertyMutex.Lock()
+	defer dazeus.propertyMutex.Unlock()
+
+	value, found, err := dazeus.lookupProperty(ctx, property, scope)
+	if err != nil {
+		return false, err
+	}
+
+	// a value that is not a string never equals old, even the empty string an unset property has
+	current, ok := value.(string)
+	if found && !ok || current != old {
+		return false, nil
+	}
+
+	err = dazeus.SetPropertyContext(ctx, property, new, scope)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SetPropertyIfAbsent sets a property only if it is not set yet, and reports whether it was set. A property set to
+// any value, including the empty string, is left alone. This is only atomic with respect to other calls of
+// SetPropertyIfAbsent and compare-and-swap calls done by the library itself on the same connection.
+func (dazeus *DaZeus) SetPropertyIfAbsent(property string, value string, scope Scope) (bool, error) {
+	return dazeus.SetPropertyIfAbsentContext(context.Background(), property, value, scope)
+}
+
+// SetPropertyIfAbsentContext is like SetPropertyIfAbsent, but gives up waiting when the context is done
+func (dazeus *DaZeus) SetPropertyIfAbsentContext(ctx context.Context, property string, value string, scope Scope) (bool, error) {
+	dazeus.propertyMutex.Lock()
+	defer dazeus.propertyMutex.Unlock()
+
+	_, found, err := dazeus.lookupProperty(ctx, property, scope)
+	if err != nil {
+		return false, err
+	}
+	if found {
+		return false, nil
+	}
+
+	err = dazeus.SetPropertyContext(ctx, property, value, scope)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 // UnsetProperty removes a property from the DaZeus core.
 func (dazeus *DaZeus) UnsetProperty(property string, scope Scope) (err error) {
+	return dazeus.UnsetPropertyContext(context.Background(), property, scope)
+}
+
+// UnsetPropertyContext is like UnsetProperty, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) UnsetPropertyContext(ctx context.Context, property string, scope Scope) (err error) {
 	if scope.IsAll() {
-		_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
-			"params": []string{"unset", property},
+			"params": []string{"unset", dazeus.propertyKey(property)},
 		})
 	} else {
-		_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
 			"scope":  scope.ToSlice(),
-			"params": []string{"unset", property},
+			"params": []string{"unset", dazeus.propertyKey(property)},
 		})
 	}
 
@@ -400,19 +1739,24 @@ func (dazeus *DaZeus) UnsetProperty(property string, scope Scope) (err error) {
 
 // PropertyKeys retrieves all keys matching a given prefix and scope.
 func (dazeus *DaZeus) PropertyKeys(prefix string, scope Scope) ([]string, error) {
+	return dazeus.PropertyKeysContext(context.Background(), prefix, scope)
+}
+
+// PropertyKeysContext is like PropertyKeys, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) PropertyKeysContext(ctx context.Context, prefix string, scope Scope) ([]string, error) {
 	var err error
 	var resp map[string]interface{}
 
 	if scope.IsAll() {
-		resp, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		resp, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
-			"params": []string{"keys", prefix},
+			"params": []string{"keys", dazeus.propertyKey(prefix)},
 		})
 	} else {
-		resp, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+		resp, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 			"do":     "property",
 			"scope":  scope.ToSlice(),
-			"params": []string{"keys", prefix},
+			"params": []string{"keys", dazeus.propertyKey(prefix)},
 		})
 	}
 
@@ -420,16 +1764,80 @@ func (dazeus *DaZeus) PropertyKeys(prefix string, scope Scope) ([]string, error)
 		return nil, err
 	}
 
-	return makeStringArray(resp["keys"])
+	keys, err := makeStringArray(resp["keys"])
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace := dazeus.propertyNamespace(); namespace != "" {
+		for i, key := range keys {
+			keys[i] = strings.TrimPrefix(key, namespace+".")
+		}
+	}
+
+	return keys, nil
+}
+
+// PropertyKeysMatching retrieves all keys matching a pattern in a given scope, where * matches any number of
+// characters and ? matches a single character. The core is asked for the keys starting with the part of the
+// pattern before the first wildcard, the rest of the pattern is matched by the library.
+func (dazeus *DaZeus) PropertyKeysMatching(pattern string, scope Scope) ([]string, error) {
+	return dazeus.PropertyKeysMatchingContext(context.Background(), pattern, scope)
+}
+
+// PropertyKeysMatchingContext is like PropertyKeysMatching, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) PropertyKeysMatchingContext(ctx context.Context, pattern string, scope Scope) ([]string, error) {
+	prefix := pattern
+	if i := strings.IndexAny(pattern, "*?"); i >= 0 {
+		prefix = pattern[:i]
+	}
+
+	keys, err := dazeus.PropertyKeysContext(ctx, prefix, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if wildcardMatch(pattern, key) {
+			matching = append(matching, key)
+		}
+	}
+
+	return matching, nil
+}
+
+// propertyNamespace returns the namespace properties are stored under, or an empty string if properties are not
+// namespaced.
+func (dazeus *DaZeus) propertyNamespace() string {
+	if !dazeus.namespaceProperties || dazeus.namespace != "" {
+		return dazeus.namespace
+	}
+
+	return dazeus.pluginName
+}
+
+// propertyKey returns the key under which a property is stored in the core.
+func (dazeus *DaZeus) propertyKey(property string) string {
+	if namespace := dazeus.propertyNamespace(); namespace != "" {
+		return namespace + "." + property
+	}
+
+	return property
 }
 
 // HasPermission checks if a permission is given for the given scope.
 func (dazeus *DaZeus) HasPermission(permission string, scope Scope, allow bool) (bool, error) {
+	return dazeus.HasPermissionContext(context.Background(), permission, scope, allow)
+}
+
+// HasPermissionContext is like HasPermission, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) HasPermissionContext(ctx context.Context, permission string, scope Scope, allow bool) (bool, error) {
 	if scope.IsAll() {
 		return false, errors.New("Will not check permission for universal scope")
 	}
 
-	resp, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+	resp, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"do":     "permission",
 		"scope":  scope.ToSlice(),
 		"params": []interface{}{"has", permission, allow},
@@ -448,11 +1856,16 @@ func (dazeus *DaZeus) HasPermission(permission string, scope Scope, allow bool)
 
 // SetPermission sets a permission for a given scope.
 func (dazeus *DaZeus) SetPermission(permission string, scope Scope, allow bool) (err error) {
+	return dazeus.SetPermissionContext(context.Background(), permission, scope, allow)
+}
+
+// SetPermissionContext is like SetPermission, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SetPermissionContext(ctx context.Context, permission string, scope Scope, allow bool) (err error) {
 	if scope.IsAll() {
 		return errors.New("Will not set permission for universal scope")
 	}
 
-	_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+	_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"do":     "permission",
 		"scope":  scope.ToSlice(),
 		"params": []interface{}{"set", permission, allow},
@@ -462,11 +1875,16 @@ func (dazeus *DaZeus) SetPermission(permission string, scope Scope, allow bool)
 
 // UnsetPermission removes a permission for some scope.
 func (dazeus *DaZeus) UnsetPermission(permission string, scope Scope) (err error) {
+	return dazeus.UnsetPermissionContext(context.Background(), permission, scope)
+}
+
+// UnsetPermissionContext is like UnsetPermission, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) UnsetPermissionContext(ctx context.Context, permission string, scope Scope) (err error) {
 	if scope.IsAll() {
 		return errors.New("Will not remove permission for universal scope")
 	}
 
-	_, err = writeForSuccessResponse(dazeus, map[string]interface{}{
+	_, err = writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"do":     "permission",
 		"scope":  scope.ToSlice(),
 		"params": []interface{}{"unset", permission},
@@ -476,7 +1894,12 @@ func (dazeus *DaZeus) UnsetPermission(permission string, scope Scope) (err error
 
 // Whois sends a whois request for some nick in some network.
 func (dazeus *DaZeus) Whois(network string, nick string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+	return dazeus.WhoisContext(context.Background(), network, nick)
+}
+
+// WhoisContext is like Whois, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) WhoisContext(ctx context.Context, network string, nick string) error {
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"do":     "whois",
 		"params": []string{network, nick},
 	})
@@ -486,7 +1909,12 @@ func (dazeus *DaZeus) Whois(network string, nick string) error {
 
 // Names sends a names request to some channel in some network, retrieving all nicks in that channel.
 func (dazeus *DaZeus) Names(network string, channel string) error {
-	_, err := writeForSuccessResponse(dazeus, map[string]interface{}{
+	return dazeus.NamesContext(context.Background(), network, channel)
+}
+
+// NamesContext is like Names, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) NamesContext(ctx context.Context, network string, channel string) error {
+	_, err := writeForSuccessResponse(ctx, dazeus, map[string]interface{}{
 		"do":     "names",
 		"params": []string{network, channel},
 	})
@@ -496,64 +1924,84 @@ func (dazeus *DaZeus) Names(network string, channel string) error {
 
 // Reply replies with a normal message to the correct channel.
 func (dazeus *DaZeus) Reply(network string, channel string, sender string, message string, highlight bool) error {
-	nick, err := dazeus.Nick(network)
+	return dazeus.ReplyContext(context.Background(), network, channel, sender, message, highlight)
+}
+
+// ReplyContext is like Reply, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ReplyContext(ctx context.Context, network string, channel string, sender string, message string, highlight bool) error {
+	nick, err := dazeus.NickContext(ctx, network)
 	if err != nil {
 		return err
 	}
 
 	if channel == nick {
-		return dazeus.Message(network, sender, message)
+		return dazeus.MessageContext(ctx, network, sender, message)
 	}
 
 	if highlight {
 		message = sender + ": " + message
 	}
 
-	return dazeus.Message(network, channel, message)
+	return dazeus.MessageContext(ctx, network, channel, message)
 }
 
 // ReplyNotice replies with a notice to the correct channel.
 func (dazeus *DaZeus) ReplyNotice(network string, channel string, sender string, message string, highlight bool) error {
-	nick, err := dazeus.Nick(network)
+	return dazeus.ReplyNoticeContext(context.Background(), network, channel, sender, message, highlight)
+}
+
+// ReplyNoticeContext is like ReplyNotice, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ReplyNoticeContext(ctx context.Context, network string, channel string, sender string, message string, highlight bool) error {
+	nick, err := dazeus.NickContext(ctx, network)
 	if err != nil {
 		return err
 	}
 
 	if channel == nick {
-		return dazeus.Notice(network, sender, message)
+		return dazeus.NoticeContext(ctx, network, sender, message)
 	}
 
 	if highlight {
 		message = sender + ": " + message
 	}
 
-	return dazeus.Notice(network, channel, message)
+	return dazeus.NoticeContext(ctx, network, channel, message)
 }
 
 // ReplyAction replies with a CTCP action to the correct channel.
 func (dazeus *DaZeus) ReplyAction(network string, channel string, sender string, message string) error {
-	nick, err := dazeus.Nick(network)
+	return dazeus.ReplyActionContext(context.Background(), network, channel, sender, message)
+}
+
+// ReplyActionContext is like ReplyAction, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ReplyActionContext(ctx context.Context, network string, channel string, sender string, message string) error {
+	nick, err := dazeus.NickContext(ctx, network)
 	if err != nil {
 		return err
 	}
 
 	if channel == nick {
-		return dazeus.Action(network, sender, message)
+		return dazeus.ActionContext(ctx, network, sender, message)
 	}
 
-	return dazeus.Action(network, channel, message)
+	return dazeus.ActionContext(ctx, network, channel, message)
 }
 
 // ReplyCtcpReply replies with a CTCP Reply to the correct channel.
 func (dazeus *DaZeus) ReplyCtcpReply(network string, channel string, sender string, message string) error {
-	nick, err := dazeus.Nick(network)
+	return dazeus.ReplyCtcpReplyContext(context.Background(), network, channel, sender, message)
+}
+
+// ReplyCtcpReplyContext is like ReplyCtcpReply, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) ReplyCtcpReplyContext(ctx context.Context, network string, channel string, sender string, message string) error {
+	nick, err := dazeus.NickContext(ctx, network)
 	if err != nil {
 		return err
 	}
 
 	if channel == nick {
-		return dazeus.CtcpReply(network, sender, message)
+		return dazeus.CtcpReplyContext(ctx, network, sender, message)
 	}
 
-	return dazeus.CtcpReply(network, channel, message)
+	return dazeus.CtcpReplyContext(ctx, network, channel, message)
 }
@@ -0,0 +1,473 @@
+package dazeus_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dazeus/dazeus-go"
+	"github.com/dazeus/dazeus-go/dazeustest"
+)
+
+func TestConcurrentRequestsGetTheirOwnResponse(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+	core.SetResponder("get", "config", func(request dazeus.Message) dazeus.Message {
+		params := request["params"].([]interface{})
+		return dazeus.Message{"value": "value of " + params[1].(string)}
+	})
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+
+			value, err := client.GetConfig(key, "plugin")
+			if err != nil {
+				errs <- err
+				return
+			}
+			if value != "value of "+key {
+				errs <- fmt.Errorf("GetConfig(%q) = %q", key, value)
+			}
+		}(fmt.Sprintf("key%d", i))
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestEventsWithoutListenerAreNotQueued(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+	core.SetResponse("get", "networks", dazeus.Message{"networks": []string{}})
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	// refreshing the cached state subscribes to NAMES events for the library itself, without a listener
+	err := client.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Subscribe(dazeus.EventPart, func(dazeus.Event) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		err = core.Inject("NAMES", "net", "server", "#chan", "someone")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		err = core.Inject("PART", "net", "someone", "#chan")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the response arrives after the events, so the reader handled them all once it is received
+	_, err = client.Networks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if queued := client.Stats().QueuedEvents; queued != 10 {
+		t.Errorf("QueuedEvents = %d, want 10", queued)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+	core.SetResponse("get", "networks", dazeus.Message{"networks": []string{"net1", "net2"}})
+	core.SetResponder("get", "nick", func(request dazeus.Message) dazeus.Message {
+		params := request["params"].([]interface{})
+		return dazeus.Message{"nick": "bot@" + params[0].(string)}
+	})
+	core.SetResponder("get", "channels", func(request dazeus.Message) dazeus.Message {
+		params := request["params"].([]interface{})
+		return dazeus.Message{"channels": []string{"#" + params[0].(string)}}
+	})
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	state, err := client.Bootstrap()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, network := range []string{"net1", "net2"} {
+		if nick := state.Nicks[network]; nick != "bot@"+network {
+			t.Errorf("Nicks[%q] = %q", network, nick)
+		}
+		if channels := state.Channels[network]; len(channels) != 1 || channels[0] != "#"+network {
+			t.Errorf("Channels[%q] = %v", network, channels)
+		}
+	}
+}
+
+func TestListenReturnsErrClosedAfterClose(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+
+	client := core.Client(nil)
+	_, err := client.Subscribe(dazeus.EventPrivMsg, func(dazeus.Event) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Listen()
+	}()
+
+	client.Close()
+
+	select {
+	case err = <-done:
+		if err != dazeus.ErrClosed {
+			t.Errorf("Listen() = %v, want ErrClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Listen did not return after Close")
+	}
+}
+
+func TestListenContinuesAfterHandlerPanic(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	received := make(chan string, 1)
+	_, err := client.Subscribe(dazeus.EventPrivMsg, func(evt dazeus.Event) {
+		if evt.Params[0] == "panic" {
+			panic("handler failed")
+		}
+		received <- evt.Params[0]
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.ListenContext(ctx)
+
+	err = core.Inject("PRIVMSG", "net", "someone", "#chan", "panic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = core.Inject("PRIVMSG", "net", "someone", "#chan", "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case message := <-received:
+		if message != "hello" {
+			t.Errorf("received %q, want hello", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event after the panic was not handled")
+	}
+}
+
+func TestUnsubscribeFromHandler(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	var handle dazeus.ListenerHandle
+	var once int
+	handle, err := client.Subscribe(dazeus.EventPrivMsg, func(dazeus.Event) {
+		once++
+		err := client.Unsubscribe(handle)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan struct{}, 2)
+	_, err = client.Subscribe(dazeus.EventPrivMsg, func(dazeus.Event) {
+		received <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go client.ListenContext(ctx)
+
+	for i := 0; i < 2; i++ {
+		err = core.Inject("PRIVMSG", "net", "someone", "#chan", "hello")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the event was not handled")
+		}
+	}
+
+	// the listener that unsubscribed itself only got the first event
+	if once != 1 {
+		t.Errorf("the unsubscribed listener was called %d times, want 1", once)
+	}
+}
+
+func TestNamesSync(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	// the core answers the request first and sends the NAMES event later, as the real core does
+	go func() {
+		for {
+			for _, request := range core.Requests() {
+				if request["do"] == "names" {
+					core.Inject("NAMES", "net", "server", "#Chan", "@op +voice", "user")
+					return
+				}
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	nicks, err := client.NamesSync("net", "#chan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"op", "voice", "user"}
+	if fmt.Sprint(nicks) != fmt.Sprint(want) {
+		t.Errorf("NamesSync() = %v, want %v", nicks, want)
+	}
+}
+
+// newPropertyCore returns a fake core storing properties in a map, ignoring their scope. It does not support
+// compare-and-swap, so the library falls back to getting and setting the property itself.
+func newPropertyCore(properties map[string]interface{}) *dazeustest.FakeCore {
+	core := dazeustest.NewPipeFakeCore()
+
+	var mutex sync.Mutex
+	core.SetResponder("do", "property", func(request dazeus.Message) dazeus.Message {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		params := request["params"].([]interface{})
+		switch params[0] {
+		case "get":
+			return dazeus.Message{"value": properties[params[1].(string)]}
+		case "set":
+			properties[params[1].(string)] = params[2]
+			return dazeus.Message{}
+		default:
+			return dazeus.Message{"success": false}
+		}
+	})
+
+	return core
+}
+
+func TestCompareAndSwapPropertyFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  interface{}
+		old     string
+		swapped bool
+	}{
+		{"unset", nil, "", true},
+		{"equal", "old", "old", true},
+		{"different", "other", "old", false},
+		{"empty string", "", "", true},
+		{"number", 42.0, "", false},
+		{"object", map[string]interface{}{"a": "b"}, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			properties := map[string]interface{}{}
+			if test.stored != nil {
+				properties["key"] = test.stored
+			}
+			core := newPropertyCore(properties)
+			defer core.Close()
+
+			client := core.Client(nil)
+			defer client.Close()
+
+			swapped, err := client.CompareAndSwapProperty("key", test.old, "new", dazeus.NewUniversalScope())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if swapped != test.swapped {
+				t.Errorf("CompareAndSwapProperty() = %v, want %v", swapped, test.swapped)
+			}
+
+			want := test.stored
+			if test.swapped {
+				want = "new"
+			}
+			if fmt.Sprint(properties["key"]) != fmt.Sprint(want) {
+				t.Errorf("property is %v, want %v", properties["key"], want)
+			}
+		})
+	}
+}
+
+func TestSetPropertyIfAbsent(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored interface{}
+		set    bool
+	}{
+		{"unset", nil, true},
+		{"string", "value", false},
+		{"empty string", "", false},
+		{"number", 42.0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			properties := map[string]interface{}{}
+			if test.stored != nil {
+				properties["key"] = test.stored
+			}
+			core := newPropertyCore(properties)
+			defer core.Close()
+
+			client := core.Client(nil)
+			defer client.Close()
+
+			set, err := client.SetPropertyIfAbsent("key", "new", dazeus.NewUniversalScope())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if set != test.set {
+				t.Errorf("SetPropertyIfAbsent() = %v, want %v", set, test.set)
+			}
+
+			want := test.stored
+			if test.set {
+				want = "new"
+			}
+			if fmt.Sprint(properties["key"]) != fmt.Sprint(want) {
+				t.Errorf("property is %v, want %v", properties["key"], want)
+			}
+		})
+	}
+}
+
+// sentMessages returns the messages the plugin asked the core to send
+func sentMessages(core *dazeustest.FakeCore) []string {
+	messages := make([]string, 0)
+	for _, request := range core.Requests() {
+		if request["do"] == "message" {
+			params := request["params"].([]interface{})
+			messages = append(messages, params[2].(string))
+		}
+	}
+
+	return messages
+}
+
+func TestReplyWriter(t *testing.T) {
+	core := dazeustest.NewPipeFakeCore()
+	defer core.Close()
+	core.SetResponse("get", "nick", dazeus.Message{"nick": "bot"})
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	evt := dazeus.Event{DaZeus: client, Network: "net", Channel: "#chan", Sender: "nick"}
+	writer := evt.ReplyWriterContext(ctx)
+
+	// the pacing allows a burst of lines without waiting, each line is sent as soon as it is written
+	for i := 1; i <= 4; i++ {
+		_, err := fmt.Fprintf(writer, "line %d\n", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sent := sentMessages(core)
+		if len(sent) != i || sent[i-1] != fmt.Sprintf("line %d", i) {
+			t.Fatalf("sent %q after writing %d lines", sent, i)
+		}
+	}
+
+	// after the burst the writer waits for the pacing, which is interrupted by the context
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := fmt.Fprintln(writer, "line 5")
+	if err != context.Canceled {
+		t.Errorf("Write() = %v, want context.Canceled", err)
+	}
+	if sent := sentMessages(core); len(sent) != 4 {
+		t.Errorf("sent %q, want only the first 4 lines", sent)
+	}
+}
+
+func TestSenderProperty(t *testing.T) {
+	properties := map[string]interface{}{}
+	core := newPropertyCore(properties)
+	defer core.Close()
+
+	client := core.Client(nil)
+	defer client.Close()
+
+	evt := dazeus.Event{DaZeus: client, Network: "net", Sender: "nick"}
+	value, err := evt.GetSenderProperty("key")
+	if err != nil || value != "" {
+		t.Errorf("GetSenderProperty() of an unset property = %q, %v", value, err)
+	}
+
+	err = evt.SetSenderProperty("key", "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, err = evt.GetSenderProperty("key")
+	if err != nil || value != "value" {
+		t.Errorf("GetSenderProperty() = %q, %v, want value", value, err)
+	}
+
+	// an event without a sender has no sender scope, so nothing is read or written
+	requests := len(core.Requests())
+	noSender := dazeus.Event{DaZeus: client, Network: "net"}
+	if _, err = noSender.GetSenderProperty("key"); err == nil {
+		t.Error("GetSenderProperty() succeeded for an event without a sender")
+	}
+	if err = noSender.SetSenderProperty("key", "other"); err == nil {
+		t.Error("SetSenderProperty() succeeded for an event without a sender")
+	}
+	if n := len(core.Requests()); n != requests {
+		t.Errorf("%d requests were sent for an event without a sender", n-requests)
+	}
+}
@@ -0,0 +1,306 @@
+// Package dazeustest provides a fake DaZeus core for testing plugins without running the real core.
+package dazeustest
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/dazeus/dazeus-go"
+)
+
+// FakeCore is a minimal DaZeus core that speaks the plugin protocol over a local socket or in-memory pipes. It
+// accepts subscriptions, answers requests with canned responses and can inject events into the connected plugins.
+type FakeCore struct {
+	listener      net.Listener
+	mutex         sync.Mutex
+	conns         map[*fakeConn]bool
+	responses     map[string]dazeus.Message
+	responders    map[string]func(dazeus.Message) dazeus.Message
+	requests      []dazeus.Message
+	subscriptions map[string]bool
+	commands      map[string]bool
+}
+
+// fakeConn is a single plugin connected to the fake core
+type fakeConn struct {
+	conn  net.Conn
+	mutex sync.Mutex
+}
+
+// NewFakeCore starts a fake core listening on a local TCP socket
+func NewFakeCore() (*FakeCore, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	core := NewPipeFakeCore()
+	core.listener = listener
+
+	go core.accept()
+	return core, nil
+}
+
+// NewPipeFakeCore creates a fake core without a socket, plugins connect to it in memory through Pipe or Client
+func NewPipeFakeCore() *FakeCore {
+	return &FakeCore{
+		conns:         make(map[*fakeConn]bool),
+		responses:     make(map[string]dazeus.Message),
+		responders:    make(map[string]func(dazeus.Message) dazeus.Message),
+		requests:      make([]dazeus.Message, 0),
+		subscriptions: make(map[string]bool),
+		commands:      make(map[string]bool),
+	}
+}
+
+// ConnectionString returns the connection string a plugin can use to connect to the fake core, it is empty when
+// the fake core was created by NewPipeFakeCore
+func (core *FakeCore) ConnectionString() string {
+	if core.listener == nil {
+		return ""
+	}
+
+	return "tcp:" + core.listener.Addr().String()
+}
+
+// Pipe connects to the fake core in memory, returning the end of the connection for the plugin
+func (core *FakeCore) Pipe() net.Conn {
+	client, server := net.Pipe()
+	core.serveConn(server)
+	return client
+}
+
+// Client connects a new client to the fake core in memory, a nil logger discards the log of the client
+func (core *FakeCore) Client(logger *log.Logger) *dazeus.DaZeus {
+	return dazeus.NewWithConn(core.Pipe(), logger)
+}
+
+// SetResponse sets the response for a request, where kind is either "get" or "do" and name is the value of that
+// field in the request. Unless the response says otherwise it is marked as successful.
+func (core *FakeCore) SetResponse(kind string, name string, response dazeus.Message) {
+	core.mutex.Lock()
+	defer core.mutex.Unlock()
+
+	core.responses[kind+" "+name] = response
+}
+
+// SetResponder sets a function building the response for a request, for responses that depend on the parameters
+// of the request. It takes precedence over a response set with SetResponse.
+func (core *FakeCore) SetResponder(kind string, name string, responder func(request dazeus.Message) dazeus.Message) {
+	core.mutex.Lock()
+	defer core.mutex.Unlock()
+
+	core.responders[kind+" "+name] = responder
+}
+
+// Requests returns all requests the fake core received so far
+func (core *FakeCore) Requests() []dazeus.Message {
+	core.mutex.Lock()
+	defer core.mutex.Unlock()
+
+	return append([]dazeus.Message(nil), core.requests...)
+}
+
+// Subscribed indicates if a plugin subscribed to the given event type
+func (core *FakeCore) Subscribed(event string) bool {
+	core.mutex.Lock()
+	defer core.mutex.Unlock()
+
+	return core.subscriptions[event]
+}
+
+// Inject sends an event to all connected plugins. Just like the real core the event is only sent if a plugin
+// subscribed to it, for COMMAND events the fourth parameter must be a registered command.
+func (core *FakeCore) Inject(event string, params ...string) error {
+	core.mutex.Lock()
+	subscribed := core.subscriptions[event]
+	if event == "COMMAND" {
+		subscribed = len(params) > 3 && core.commands[params[3]]
+	}
+	conns := make([]*fakeConn, 0, len(core.conns))
+	for conn := range core.conns {
+		conns = append(conns, conn)
+	}
+	core.mutex.Unlock()
+
+	if !subscribed {
+		return errors.New("No plugin subscribed to event")
+	}
+
+	for _, conn := range conns {
+		err := conn.write(dazeus.Message{
+			"event":  event,
+			"params": params,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close stops the fake core and disconnects all plugins
+func (core *FakeCore) Close() error {
+	var err error
+	if core.listener != nil {
+		err = core.listener.Close()
+	}
+
+	core.mutex.Lock()
+	defer core.mutex.Unlock()
+
+	for conn := range core.conns {
+		conn.conn.Close()
+	}
+
+	return err
+}
+
+func (core *FakeCore) accept() {
+	for {
+		conn, err := core.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		core.serveConn(conn)
+	}
+}
+
+// serveConn registers a connection of a plugin and starts serving it
+func (core *FakeCore) serveConn(conn net.Conn) {
+	fc := &fakeConn{conn: conn}
+	core.mutex.Lock()
+	core.conns[fc] = true
+	core.mutex.Unlock()
+
+	go core.serve(fc)
+}
+
+func (core *FakeCore) serve(conn *fakeConn) {
+	defer func() {
+		core.mutex.Lock()
+		delete(core.conns, conn)
+		core.mutex.Unlock()
+		conn.conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn.conn)
+	for {
+		request, err := readFrame(reader)
+		if err != nil {
+			return
+		}
+
+		err = conn.write(core.respond(request))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// respond records a request and builds the response for it
+func (core *FakeCore) respond(request dazeus.Message) dazeus.Message {
+	core.mutex.Lock()
+	defer core.mutex.Unlock()
+
+	core.requests = append(core.requests, request)
+
+	kind, responseKind := "do", "did"
+	name, ok := request["do"].(string)
+	if !ok {
+		kind, responseKind = "get", "got"
+		name, _ = request["get"].(string)
+	}
+
+	params, _ := request["params"].([]interface{})
+	if kind == "do" {
+		switch name {
+		case "subscribe", "unsubscribe":
+			for _, param := range params {
+				if event, ok := param.(string); ok {
+					core.subscriptions[event] = name == "subscribe"
+				}
+			}
+		case "command":
+			if len(params) > 0 {
+				if command, ok := params[0].(string); ok {
+					core.commands[command] = true
+				}
+			}
+		}
+	}
+
+	response := dazeus.Message{
+		responseKind: name,
+		"success":    true,
+	}
+	canned := core.responses[kind+" "+name]
+	if responder := core.responders[kind+" "+name]; responder != nil {
+		canned = responder(request)
+	}
+	for key, value := range canned {
+		response[key] = value
+	}
+
+	return response
+}
+
+func (conn *fakeConn) write(message dazeus.Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+
+	_, err = conn.conn.Write(append([]byte(strconv.Itoa(len(payload))), payload...))
+	return err
+}
+
+// readFrame reads a single length-prefixed JSON message
+func readFrame(reader *bufio.Reader) (dazeus.Message, error) {
+	length := 0
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		if b >= '0' && b <= '9' {
+			length = length*10 + int(b-'0')
+		} else if b != '\n' && b != '\r' {
+			err = reader.UnreadByte()
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if length == 0 {
+		return nil, errors.New("Missing message length")
+	}
+
+	payload := make([]byte, length)
+	_, err := io.ReadFull(reader, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	message := make(dazeus.Message)
+	err = json.Unmarshal(payload, &message)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
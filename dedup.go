@@ -0,0 +1,38 @@
+package dazeus
+
+import "time"
+
+// sentMessage is the last message sent to a target, used for suppressing duplicates
+type sentMessage struct {
+	message string
+	at      time.Time
+}
+
+// isDuplicate indicates if the message is the same as the last message sent to the target within the duplicate
+// suppression window
+func (dazeus *DaZeus) isDuplicate(network string, channel string, message string) bool {
+	if dazeus.duplicateWindow <= 0 {
+		return false
+	}
+
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	last, ok := dazeus.lastSent[muteKey(network, channel)]
+	return ok && last.message == message && dazeus.clock.Now().Sub(last.at) < dazeus.duplicateWindow
+}
+
+// recordSent remembers the last message sent to a target for suppressing duplicates
+func (dazeus *DaZeus) recordSent(network string, channel string, message string) {
+	if dazeus.duplicateWindow <= 0 {
+		return
+	}
+
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	if dazeus.lastSent == nil {
+		dazeus.lastSent = make(map[string]sentMessage)
+	}
+	dazeus.lastSent[muteKey(network, channel)] = sentMessage{message: message, at: dazeus.clock.Now()}
+}
@@ -0,0 +1,28 @@
+package dazeus
+
+// RunningHandlers returns how many event handlers are currently running, including abandoned ones still running in
+// the background, for debugging handlers that do not return.
+func (dazeus *DaZeus) RunningHandlers() int {
+	return int(dazeus.runningHandlers.Load())
+}
+
+// PendingResponses returns how many requests are waiting for a response from the core, for debugging requests that
+// do not return.
+func (dazeus *DaZeus) PendingResponses() int {
+	return dazeus.connection.Load().waiting()
+}
+
+// ResetProtocolState drops the connection to the core, to recover when responses no longer match up with the
+// requests waiting for them and requests hang. All requests still waiting fail, and the connection is re-established
+// by Listen when reconnecting is enabled. It only does this when the core sent a response no request was waiting for,
+// a request that is merely slow is left alone. It reports whether the connection was dropped.
+func (dazeus *DaZeus) ResetProtocolState() bool {
+	c := dazeus.connection.Load()
+	if !c.outOfSync.Load() {
+		return false
+	}
+
+	dazeus.logger.Printf("Resetting protocol state with %d requests waiting for a response", c.waiting())
+	c.conn.Close()
+	return true
+}
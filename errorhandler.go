@@ -0,0 +1,59 @@
+package dazeus
+
+import (
+	"context"
+)
+
+// ErrorHandler defines the function type for registering a callback that can fail
+type ErrorHandler func(Event) error
+
+// handlerError is an error returned by an event handler, queued to stop Listen
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+func (e *handlerError) Unwrap() error {
+	return e.err
+}
+
+// SubscribeWithError subscribes to an event type like Subscribe, with a handler that can return an error. Errors
+// are logged, and stop Listen when WithStopOnHandlerError is set.
+func (dazeus *DaZeus) SubscribeWithError(event eventType, handler ErrorHandler) (ListenerHandle, error) {
+	return dazeus.SubscribeWithErrorContext(context.Background(), event, handler)
+}
+
+// SubscribeWithErrorContext is like SubscribeWithError, but gives up waiting for the core when the context is done
+func (dazeus *DaZeus) SubscribeWithErrorContext(ctx context.Context, event eventType, handler ErrorHandler) (ListenerHandle, error) {
+	return dazeus.SubscribeContext(ctx, event, dazeus.wrapErrorHandler(handler))
+}
+
+// SubscribeCommandWithError subscribes to a command like SubscribeCommand, with a handler that can return an error.
+// Errors are logged, and stop Listen when WithStopOnHandlerError is set.
+func (dazeus *DaZeus) SubscribeCommandWithError(command string, scope Scope, handler ErrorHandler) (ListenerHandle, error) {
+	return dazeus.SubscribeCommandWithErrorContext(context.Background(), command, scope, handler)
+}
+
+// SubscribeCommandWithErrorContext is like SubscribeCommandWithError, but gives up waiting for the core when the
+// context is done
+func (dazeus *DaZeus) SubscribeCommandWithErrorContext(ctx context.Context, command string, scope Scope, handler ErrorHandler) (ListenerHandle, error) {
+	return dazeus.SubscribeCommandContext(ctx, command, scope, dazeus.wrapErrorHandler(handler))
+}
+
+// wrapErrorHandler turns a handler that can fail into a Handler that reports its errors
+func (dazeus *DaZeus) wrapErrorHandler(handler ErrorHandler) Handler {
+	return func(evt Event) {
+		err := handler(evt)
+		if err == nil {
+			return
+		}
+
+		dazeus.logger.Printf("Event handler for event of type '%s' failed: %v", evt.Event, err)
+		if dazeus.stopOnHandlerError {
+			dazeus.incoming.push(incoming{err: &handlerError{err}})
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package dazeus
 
 import (
+	"context"
 	"errors"
+	"runtime/debug"
+	"strings"
+	"time"
 )
 
 type eventType string
@@ -57,6 +61,50 @@ const (
 	EventCommand eventType = "COMMAND"
 )
 
+// knownEventTypes lists all event types the core is known to send
+var knownEventTypes = []eventType{
+	EventConnect,
+	EventDisconnect,
+	EventJoin,
+	EventPart,
+	EventQuit,
+	EventNick,
+	EventMode,
+	EventTopic,
+	EventInvite,
+	EventKick,
+	EventPrivMsg,
+	EventNotice,
+	EventCtcp,
+	EventCtcpReply,
+	EventAction,
+	EventNumeric,
+	EventUnknown,
+	EventWhois,
+	EventNames,
+	EventPrivMsgMe,
+	EventCtcpMe,
+	EventActionMe,
+	EventPong,
+	EventCommand,
+}
+
+// KnownEventTypes returns all event types the core is known to send
+func KnownEventTypes() []eventType {
+	return append([]eventType(nil), knownEventTypes...)
+}
+
+// isKnownEventType indicates if the event type is one of the known event types
+func isKnownEventType(event eventType) bool {
+	for _, known := range knownEventTypes {
+		if event == known {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Event represents an event message
 type Event struct {
 	Event   eventType
@@ -66,43 +114,425 @@ type Event struct {
 	Channel string
 	Sender  string
 	Command string
+	// Time is when the event happened, according to the server when it sent a server-time tag, otherwise when it
+	// was received
+	Time time.Time
+}
+
+// IsKnown indicates if the type of the event is one of the known event types. Unknown types are events added to the
+// core after this library was written.
+func (event *Event) IsKnown() bool {
+	return isKnownEventType(event.Event)
 }
 
-// Reply allows an event handler to respond to the event with a message
+// Reply allows an event handler to respond to the event with a message, following the reply defaults of the network
 func (event *Event) Reply(message string, highlight bool) error {
-	return event.DaZeus.Reply(event.Network, event.Channel, event.Sender, message, highlight)
+	return event.ReplyContext(context.Background(), message, highlight)
+}
+
+// ReplyContext is like Reply, but gives up waiting for the core when the context is done
+func (event *Event) ReplyContext(ctx context.Context, message string, highlight bool) error {
+	defaults := event.DaZeus.ReplyDefaultsFor(event.Network)
+	highlight = defaults.Highlight.highlight(highlight)
+
+	network, channel := event.replyTarget()
+	if defaults.Notice {
+		return event.DaZeus.ReplyNoticeContext(ctx, network, channel, event.Sender, message, highlight)
+	}
+
+	return event.DaZeus.ReplyContext(ctx, network, channel, event.Sender, message, highlight)
 }
 
 // ReplyAction allows an event handler to respond to the event with a ctcp action
 func (event *Event) ReplyAction(message string) error {
-	return event.DaZeus.ReplyAction(event.Network, event.Channel, event.Sender, message)
+	network, channel := event.replyTarget()
+	return event.DaZeus.ReplyAction(network, channel, event.Sender, message)
 }
 
 // ReplyNotice allows an event handler to respond to the event with a notice
 func (event *Event) ReplyNotice(message string, highlight bool) error {
-	return event.DaZeus.ReplyNotice(event.Network, event.Channel, event.Sender, message, highlight)
+	network, channel := event.replyTarget()
+	return event.DaZeus.ReplyNotice(network, channel, event.Sender, message, highlight)
 }
 
 // ReplyCtcpReply allows an event handler to respond to the event with a ctcp reply
 func (event *Event) ReplyCtcpReply(message string) error {
-	return event.DaZeus.ReplyCtcpReply(event.Network, event.Channel, event.Sender, message)
+	network, channel := event.replyTarget()
+	return event.DaZeus.ReplyCtcpReply(network, channel, event.Sender, message)
 }
 
-func handleEvent(dazeus *DaZeus, message Message) error {
-	evt, err := makeEvent(dazeus, message)
+// ReplyPrivate responds to the event with a private message to the sender, wherever the event came from
+func (event *Event) ReplyPrivate(message string) error {
+	return event.DaZeus.Message(event.Network, event.SenderNick(), message)
+}
+
+// ReplyTo responds to the event with a message to some channel or user in the network of the event
+func (event *Event) ReplyTo(target string, message string) error {
+	return event.DaZeus.Message(event.Network, target, message)
+}
+
+// replyTarget determines where replies to the event go, using the reply target resolver if one is set
+func (event *Event) replyTarget() (network string, channel string) {
+	event.DaZeus.settingsMutex.Lock()
+	resolve := event.DaZeus.replyTargetResolver
+	event.DaZeus.settingsMutex.Unlock()
+
+	if resolve == nil {
+		return event.Network, event.Channel
+	}
+
+	return resolve(*event)
+}
+
+// ReplyTruncated replies with a message cut off at the maximum line length, ending it with an ellipsis when it
+// was cut off, instead of leaving it to the server to cut off the message.
+func (event *Event) ReplyTruncated(message string, highlight bool) error {
+	limit := event.DaZeus.maxLineLength
+	if event.DaZeus.ReplyDefaultsFor(event.Network).Highlight.highlight(highlight) {
+		limit -= len(event.Sender) + len(": ")
+	}
+
+	return event.Reply(truncate(message, limit), highlight)
+}
+
+// GetSenderProperty retrieves a property stored for the sender of the event in its network, it is empty when the
+// property is not set. An error is returned when the event has no network or sender.
+func (event *Event) GetSenderProperty(key string) (string, error) {
+	scope, err := event.NewSenderScope()
+	if err != nil {
+		return "", err
+	}
+
+	value, found, err := event.DaZeus.lookupProperty(context.Background(), key, scope)
+	if err != nil {
+		return "", err
+	}
+
+	if !found {
+		return "", nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", errors.New("Property value is not a string")
+	}
+
+	return str, nil
+}
 
+// SetSenderProperty stores a property for the sender of the event in its network. An error is returned when the
+// event has no network or sender.
+func (event *Event) SetSenderProperty(key string, value string) error {
+	scope, err := event.NewSenderScope()
 	if err != nil {
 		return err
 	}
 
+	return event.DaZeus.SetProperty(key, value, scope)
+}
+
+// SenderNick returns the nick of the sender, which is the whole sender when it is not a nick!user@host prefix
+func (event *Event) SenderNick() string {
+	nick, _, _, _ := ParseHostmask(event.Sender)
+	return nick
+}
+
+// SenderUser returns the user part of the sender's nick!user@host prefix, or an empty string when it has none
+func (event *Event) SenderUser() string {
+	_, user, _, _ := ParseHostmask(event.Sender)
+	return user
+}
+
+// SenderHost returns the host part of the sender's nick!user@host prefix, or an empty string when it has none
+func (event *Event) SenderHost() string {
+	_, _, host, _ := ParseHostmask(event.Sender)
+	return host
+}
+
+// QuitReason returns the quit message of a QUIT event. The core sends no channel for QUIT events, so the quit
+// message is found where the channel would be.
+func (event *Event) QuitReason() string {
+	if event.Event != EventQuit {
+		return ""
+	}
+
+	return event.Channel
+}
+
+// NickChange returns the old and new nick of a NICK event, ok is false for other events.
+func (event *Event) NickChange() (old string, new string, ok bool) {
+	if event.Event != EventNick || event.Channel == "" {
+		return "", "", false
+	}
+
+	return event.Sender, event.Channel, true
+}
+
+// TopicChange returns the details of a TOPIC event, ok is false for other events. The new topic is empty when the
+// topic was removed, and setBy is empty when the core did not say who set the topic, for example when it reports
+// the setter in a separate numeric event.
+func (event *Event) TopicChange() (channel string, newTopic string, setBy string, ok bool) {
+	if event.Event != EventTopic {
+		return "", "", "", false
+	}
+
+	if len(event.Params) > 0 {
+		newTopic = event.Params[0]
+	}
+
+	return event.Channel, newTopic, event.Sender, true
+}
+
+// Args splits the text of the event, such as the arguments of a command or the message of a PRIVMSG, into
+// arguments with shell-like quoting, see ParseArgs.
+func (event *Event) Args() ([]string, error) {
+	if len(event.Params) == 0 {
+		return []string{}, nil
+	}
+
+	return ParseArgs(event.Params[0])
+}
+
+// AddressedToBot detects if the message of the event is addressed to the bot, either because it starts with the
+// highlight character or with the nick of the bot followed by a colon or comma, like `BotNick: do something`.
+// It returns the rest of the message after that.
+func (event *Event) AddressedToBot() (rest string, ok bool) {
+	if len(event.Params) == 0 {
+		return "", false
+	}
+
+	message := event.Params[0]
+	if rest, ok := event.DaZeus.StripCommandPrefix(message); ok {
+		return strings.TrimSpace(rest), true
+	}
+
+	nick, err := event.DaZeus.Nick(event.Network)
+	if err != nil || nick == "" || len(message) <= len(nick) || !CaseRFC1459.Equal(message[:len(nick)], nick) {
+		return "", false
+	}
+
+	rest = message[len(nick):]
+	if rest[0] != ':' && rest[0] != ',' {
+		return "", false
+	}
+
+	return strings.TrimSpace(rest[1:]), true
+}
+
+// eventWaiter waits for a single event matching some condition
+type eventWaiter struct {
+	match func(Event) bool
+	event chan Event
+	// closed is closed when the connection is closed before the event arrived
+	closed chan struct{}
+}
+
+// addWaiter registers a waiter for the first event matching the given condition
+func (dazeus *DaZeus) addWaiter(match func(Event) bool) *eventWaiter {
+	waiter := &eventWaiter{
+		match:  match,
+		event:  make(chan Event, 1),
+		closed: make(chan struct{}),
+	}
+
+	dazeus.waitersMutex.Lock()
+	defer dazeus.waitersMutex.Unlock()
+
+	if dazeus.isClosed() {
+		close(waiter.closed)
+		return waiter
+	}
+	dazeus.waiters = append(dazeus.waiters, waiter)
+
+	return waiter
+}
+
+// removeWaiter unregisters a waiter that is no longer interested in events
+func (dazeus *DaZeus) removeWaiter(waiter *eventWaiter) {
+	dazeus.waitersMutex.Lock()
+	defer dazeus.waitersMutex.Unlock()
+
+	for i, w := range dazeus.waiters {
+		if w == waiter {
+			dazeus.waiters = append(dazeus.waiters[:i], dazeus.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// notifyWaiters hands the event to all waiters waiting for it
+func (dazeus *DaZeus) notifyWaiters(evt Event) {
+	dazeus.waitersMutex.Lock()
+	defer dazeus.waitersMutex.Unlock()
+
+	remaining := dazeus.waiters[:0]
+	for _, waiter := range dazeus.waiters {
+		if waiter.match(evt) {
+			waiter.event <- evt
+		} else {
+			remaining = append(remaining, waiter)
+		}
+	}
+	dazeus.waiters = remaining
+}
+
+// cancelWaiters wakes up all waiters because the connection is closed
+func (dazeus *DaZeus) cancelWaiters() {
+	dazeus.waitersMutex.Lock()
+	defer dazeus.waitersMutex.Unlock()
+
+	for _, waiter := range dazeus.waiters {
+		close(waiter.closed)
+	}
+	dazeus.waiters = nil
+}
+
+// receiveEvent decodes an event as soon as it is received, applying it to the cached state and handing it to the
+// waiters waiting for it, before it is dispatched to the event handlers
+func (dazeus *DaZeus) receiveEvent(message Message) (Event, error) {
+	evt, err := makeEvent(dazeus, message)
+
+	if err != nil {
+		return evt, &frameError{err}
+	}
+
+	if dazeus.logUnknownEvents && !evt.IsKnown() {
+		dazeus.logUnknownEvent(evt.Event)
+	}
+
+	dazeus.updateState(evt)
+	dazeus.notifyWaiters(evt)
+
+	return evt, nil
+}
+
+// wantsEvent indicates if an event needs to be passed on to Listen, because a listener or the incoming hook wants it
+func (dazeus *DaZeus) wantsEvent(evt Event) bool {
+	if dazeus.getIncomingHook() != nil {
+		return true
+	}
+
+	dazeus.listenersMutex.RLock()
+	defer dazeus.listenersMutex.RUnlock()
+
 	for _, l := range dazeus.listeners {
-		if l.event == evt.Event && (l.event != EventCommand || l.command == evt.Command) {
-			dazeus.logger.Print("Calling matching event handler")
-			l.handler(evt)
+		if l.matches(dazeus.commandCase, evt) {
+			return true
 		}
 	}
 
-	return nil
+	return false
+}
+
+// dispatchEvent passes an event to the matching event handlers
+func (dazeus *DaZeus) dispatchEvent(evt Event) {
+	if hook := dazeus.getIncomingHook(); hook != nil && !hook(&evt) {
+		dazeus.logger.Printf("Incoming hook dropped event of type '%s'", evt.Event)
+		return
+	}
+
+	if dazeus.IsIgnored(evt.Network, evt.Sender) {
+		dazeus.logger.Printf("Ignoring event of type '%s' from '%s'", evt.Event, evt.Sender)
+		return
+	}
+
+	dazeus.stats.eventsDispatched.Add(1)
+
+	// handlers may subscribe and unsubscribe, so they are called from a snapshot of the matching listeners
+	dazeus.listenersMutex.RLock()
+	handles := make([]ListenerHandle, 0)
+	matching := make([]listener, 0)
+	for handle, l := range dazeus.listeners {
+		if l.matches(dazeus.commandCase, evt) {
+			handles = append(handles, handle)
+			matching = append(matching, l)
+		}
+	}
+	dazeus.listenersMutex.RUnlock()
+
+	for i, l := range matching {
+		if l.once {
+			// only the first event to remove the listener gets to call it
+			_, found, ok := dazeus.removeListener(handles[i])
+			if !ok {
+				continue
+			}
+
+			err := dazeus.releaseListener(context.Background(), l, found)
+			if err != nil {
+				dazeus.logger.Printf("Could not remove one-time listener: %v", err)
+			}
+		}
+
+		dazeus.logger.Print("Calling matching event handler")
+		dazeus.callHandler(l.handler, evt)
+	}
+}
+
+// logUnknownEvent logs an unknown event type the first time it is received
+func (dazeus *DaZeus) logUnknownEvent(event eventType) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	if dazeus.unknownEvents[event] {
+		return
+	}
+
+	if dazeus.unknownEvents == nil {
+		dazeus.unknownEvents = make(map[eventType]bool)
+	}
+	dazeus.unknownEvents[event] = true
+	dazeus.logger.Printf("Received event of unknown type '%s'", event)
+}
+
+// callHandler calls an event handler, watching over it when a handler timeout is configured
+func (dazeus *DaZeus) callHandler(handler Handler, evt Event) {
+	dazeus.runningHandlers.Add(1)
+	if dazeus.handlerTimeout <= 0 {
+		defer dazeus.runningHandlers.Add(-1)
+		dazeus.runHandler(handler, evt)
+		return
+	}
+
+	// requests of a handler do not depend on Listen, so abandoned handlers can keep running in the background
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer dazeus.runningHandlers.Add(-1)
+		dazeus.runHandler(handler, evt)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-dazeus.clock.After(dazeus.handlerTimeout):
+	}
+
+	if dazeus.abandonHandlers {
+		dazeus.logger.Printf("Abandoning event handler for event of type '%s' running longer than %v", evt.Event, dazeus.handlerTimeout)
+		return
+	}
+
+	dazeus.logger.Printf("Event handler for event of type '%s' is running longer than %v", evt.Event, dazeus.handlerTimeout)
+	<-done
+}
+
+// runHandler calls an event handler, recovering from a panic in it so the other handlers still get the event
+func (dazeus *DaZeus) runHandler(handler Handler, evt Event) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		dazeus.logger.Printf("Recovered from panic in event handler for event of type '%s': %v\n%s", evt.Event, recovered, debug.Stack())
+		dazeus.reportStatus("Event handler for event of type '%s' panicked: %v", evt.Event, recovered)
+		if dazeus.onPanic != nil {
+			dazeus.onPanic(evt, recovered)
+		}
+	}()
+
+	handler(evt)
 }
 
 func makeEvent(dazeus *DaZeus, message Message) (Event, error) {
@@ -120,29 +550,31 @@ func makeEvent(dazeus *DaZeus, message Message) (Event, error) {
 	}
 
 	var network, channel, sender string
-	network = params[0]
 
-	if len(params) < 2 {
-		sender = ""
-		channel = ""
-		params = params[1:]
-	} else if len(params) < 3 {
-		sender = params[1]
-		channel = ""
-		params = params[2:]
-	} else {
+	if len(params) > 0 {
+		network = params[0]
+	}
+	if len(params) > 1 {
 		sender = params[1]
+	}
+	if len(params) > 2 {
 		channel = params[2]
-		params = params[3:]
 	}
+	params = params[min(len(params), 3):]
 
 	command := ""
-	if messageEventType == "COMMAND" {
+	if messageEventType == "COMMAND" && len(params) > 0 {
 		command = params[0]
 		params = params[1:]
 	}
 
 	evtType := eventType(messageEventType)
+	if evtType == EventCtcp || evtType == EventCtcpReply || evtType == EventCtcpMe {
+		for i, param := range params {
+			params[i] = dequoteCtcpPayload(param)
+		}
+	}
+
 	event = Event{
 		Event:   evtType,
 		Params:  params,
@@ -151,7 +583,28 @@ func makeEvent(dazeus *DaZeus, message Message) (Event, error) {
 		Channel: channel,
 		Sender:  sender,
 		Command: command,
+		Time:    eventTime(dazeus.clock, message),
 	}
 
 	return event, nil
 }
+
+// eventTime determines when an event happened, preferring the IRCv3 server-time tag over the time it was received
+func eventTime(clock Clock, message Message) time.Time {
+	tags, ok := message["tags"].(map[string]interface{})
+	if !ok {
+		return clock.Now()
+	}
+
+	serverTime, ok := tags["time"].(string)
+	if !ok {
+		return clock.Now()
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, serverTime)
+	if err != nil {
+		return clock.Now()
+	}
+
+	return t
+}
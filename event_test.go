@@ -0,0 +1,79 @@
+package dazeus
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMakeEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   string
+		params  []interface{}
+		network string
+		sender  string
+		channel string
+		command string
+		rest    []string
+	}{
+		{"no params", "CONNECT", []interface{}{}, "", "", "", "", []string{}},
+		{"network only", "CONNECT", []interface{}{"net"}, "net", "", "", "", []string{}},
+		{"network and sender", "QUIT", []interface{}{"net", "nick"}, "net", "nick", "", "", []string{}},
+		{"network, sender and channel", "JOIN", []interface{}{"net", "nick", "#chan"}, "net", "nick", "#chan", "", []string{}},
+		{"with a parameter", "PRIVMSG", []interface{}{"net", "nick", "#chan", "hello"}, "net", "nick", "#chan", "", []string{"hello"}},
+		{"command without args", "COMMAND", []interface{}{"net", "nick", "#chan", "help"}, "net", "nick", "#chan", "help", []string{}},
+		{"command with args", "COMMAND", []interface{}{"net", "nick", "#chan", "help", "me"}, "net", "nick", "#chan", "help", []string{"me"}},
+	}
+
+	dazeus := &DaZeus{clock: realClock{}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			evt, err := makeEvent(dazeus, Message{"event": test.event, "params": test.params})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if evt.Network != test.network || evt.Sender != test.sender || evt.Channel != test.channel {
+				t.Errorf("got network %q, sender %q, channel %q, want %q, %q, %q", evt.Network, evt.Sender, evt.Channel,
+					test.network, test.sender, test.channel)
+			}
+			if evt.Command != test.command {
+				t.Errorf("got command %q, want %q", evt.Command, test.command)
+			}
+			if !reflect.DeepEqual(evt.Params, test.rest) {
+				t.Errorf("got params %q, want %q", evt.Params, test.rest)
+			}
+		})
+	}
+}
+
+func TestSenderParts(t *testing.T) {
+	tests := []struct {
+		sender string
+		nick   string
+		user   string
+		host   string
+	}{
+		{"nick!user@host", "nick", "user", "host"},
+		{"nick@host", "nick", "", "host"},
+		{"nick!user", "nick", "user", ""},
+		{"nick", "nick", "", ""},
+		{"irc.example.com", "irc.example.com", "", ""},
+		{"", "", "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sender, func(t *testing.T) {
+			evt := Event{Sender: test.sender}
+			if nick := evt.SenderNick(); nick != test.nick {
+				t.Errorf("SenderNick() = %q, want %q", nick, test.nick)
+			}
+			if user := evt.SenderUser(); user != test.user {
+				t.Errorf("SenderUser() = %q, want %q", user, test.user)
+			}
+			if host := evt.SenderHost(); host != test.host {
+				t.Errorf("SenderHost() = %q, want %q", host, test.host)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package dazeus
+
+// BackpressurePolicy determines what happens to events for an event channel whose consumer can not keep up
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock waits until the consumer takes the event, which stalls the delivery of all events
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest drops the oldest event waiting in the channel to make room for the new event
+	BackpressureDropOldest
+	// BackpressureDropNewest drops the new event when the channel is full
+	BackpressureDropNewest
+)
+
+// SubscribeChan subscribes to events and delivers them on a channel buffering up to size events. The policy decides
+// what happens when the buffer is full, events dropped because of it are counted by DroppedEvents. The channel is
+// not closed when unsubscribing.
+func (dazeus *DaZeus) SubscribeChan(event eventType, size int, policy BackpressurePolicy) (<-chan Event, ListenerHandle, error) {
+	events := make(chan Event, size)
+
+	handle, err := dazeus.Subscribe(event, func(evt Event) {
+		switch policy {
+		case BackpressureDropOldest:
+			select {
+			case events <- evt:
+				return
+			default:
+			}
+
+			select {
+			case <-events:
+				dazeus.droppedEvents.Add(1)
+			default:
+			}
+
+			// the consumer might have emptied the channel in the meantime, and the channel might have no buffer at all
+			select {
+			case events <- evt:
+			default:
+				dazeus.droppedEvents.Add(1)
+			}
+		case BackpressureDropNewest:
+			select {
+			case events <- evt:
+			default:
+				dazeus.droppedEvents.Add(1)
+			}
+		default:
+			events <- evt
+		}
+	})
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return events, handle, nil
+}
+
+// DroppedEvents returns the number of events dropped because the consumer of an event channel could not keep up
+func (dazeus *DaZeus) DroppedEvents() uint64 {
+	return dazeus.droppedEvents.Load()
+}
@@ -0,0 +1,58 @@
+package dazeus
+
+import "strings"
+
+// Ignore makes the library skip events from users matching the mask in some network, for example `*!*@spam.host`.
+// Event handlers are not called for ignored users.
+func (dazeus *DaZeus) Ignore(network string, mask string) {
+	dazeus.ignoreMutex.Lock()
+	defer dazeus.ignoreMutex.Unlock()
+
+	if dazeus.ignores == nil {
+		dazeus.ignores = make(map[string][]string)
+	}
+
+	for _, m := range dazeus.ignores[network] {
+		if m == mask {
+			return
+		}
+	}
+	dazeus.ignores[network] = append(dazeus.ignores[network], mask)
+}
+
+// Unignore removes a mask added with Ignore
+func (dazeus *DaZeus) Unignore(network string, mask string) {
+	dazeus.ignoreMutex.Lock()
+	defer dazeus.ignoreMutex.Unlock()
+
+	masks := dazeus.ignores[network]
+	for i, m := range masks {
+		if m == mask {
+			dazeus.ignores[network] = append(masks[:i], masks[i+1:]...)
+			return
+		}
+	}
+}
+
+// IsIgnored indicates if a user in some network matches one of the ignore masks. The user is either a full
+// nick!user@host hostmask or just a nick, which only matches masks with a wildcard user and host part.
+func (dazeus *DaZeus) IsIgnored(network string, nick string) bool {
+	if nick == "" {
+		return false
+	}
+
+	if !strings.ContainsAny(nick, "!@") {
+		nick += "!*@*"
+	}
+
+	dazeus.ignoreMutex.Lock()
+	defer dazeus.ignoreMutex.Unlock()
+
+	for _, mask := range dazeus.ignores[network] {
+		if MatchMask(nick, mask) {
+			return true
+		}
+	}
+
+	return false
+}
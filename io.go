@@ -1,12 +1,96 @@
 package dazeus
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-func checkMessage(dazeus *DaZeus) (bool, int, int) {
+// errFailureResponse is matched by every ServerError, so errors.Is can check for any failure response
+var errFailureResponse = errors.New("Server responded with failure")
+
+// ServerError is returned when the core responds to a request with a failure. It carries the response and the
+// reason for the failure, when the core included one in an "error" or "reason" field.
+type ServerError struct {
+	Response Message
+	Reason   string
+}
+
+func newServerError(response Message) *ServerError {
+	e := &ServerError{Response: response}
+	for _, field := range []string{"error", "reason"} {
+		if reason, ok := response[field].(string); ok && reason != "" {
+			e.Reason = reason
+			break
+		}
+	}
+
+	return e
+}
+
+func (e *ServerError) Error() string {
+	if e.Reason == "" {
+		return errFailureResponse.Error()
+	}
+
+	return errFailureResponse.Error() + ": " + e.Reason
+}
+
+func (e *ServerError) Is(target error) bool {
+	return target == errFailureResponse
+}
+
+// ErrTimeout is returned when the core did not send an expected message in time
+var ErrTimeout = errors.New("Timed out waiting for the core")
+
+// ErrMessageTooLarge is returned when a message exceeds the maximum message size
+var ErrMessageTooLarge = errors.New("Message too large")
+
+// DefaultMaxMessageSize is the default maximum size in bytes of a single message exchanged with the core
+const DefaultMaxMessageSize = 1024 * 1024
+
+// defaultWaitTimeout is how long the library waits for events it needs to complete a request
+const defaultWaitTimeout = 10 * time.Second
+
+// frameError is an error in a single message from the core, which does not affect the connection itself
+type frameError struct {
+	err error
+}
+
+func (e *frameError) Error() string {
+	return e.err.Error()
+}
+
+func (e *frameError) Unwrap() error {
+	return e.err
+}
+
+// readSize is the minimum amount of free buffer space made available for each read from the socket
+const readSize = 16 * 1024
+
+// defaultBufferRetention is the default capacity above which the read buffer is shrunk once it is mostly empty again
+const defaultBufferRetention = 64 * 1024
+
+// shrinkBuffer replaces the read buffer by a smaller one when an oversized message left it with a large backing array
+func shrinkBuffer(dazeus *DaZeus) {
+	if dazeus.bufferRetention <= 0 || dazeus.buffer.Cap() <= dazeus.bufferRetention || dazeus.buffer.Len() >= readSize {
+		return
+	}
+
+	dazeus.logger.Printf("Shrinking read buffer with a capacity of %d bytes", dazeus.buffer.Cap())
+	var buffer bytes.Buffer
+	buffer.Write(dazeus.buffer.Bytes())
+	dazeus.buffer = buffer
+}
+
+func checkMessage(dazeus *DaZeus) (bool, int, int, error) {
 	var offset, messageLen int
 
 	for offset < dazeus.buffer.Len() {
@@ -15,6 +99,11 @@ func checkMessage(dazeus *DaZeus) (bool, int, int) {
 			messageLen *= 10
 			messageLen += int(curr - '0')
 			offset++
+
+			// refuse the length before buffering the message, checking every digit also prevents an overflow
+			if dazeus.maxMessageSize > 0 && messageLen > dazeus.maxMessageSize {
+				return false, 0, 0, fmt.Errorf("%w: core announced a message of over %d bytes", ErrMessageTooLarge, dazeus.maxMessageSize)
+			}
 		} else if curr == '\n' || curr == '\r' {
 			offset++
 		} else {
@@ -23,57 +112,210 @@ func checkMessage(dazeus *DaZeus) (bool, int, int) {
 	}
 
 	if messageLen > 0 && dazeus.buffer.Len() >= offset+messageLen {
-		return true, offset, messageLen
+		return true, offset, messageLen, nil
+	}
+	return false, 0, 0, nil
+}
+
+// connection is a connection to the core, with the goroutine reading from it
+type connection struct {
+	conn net.Conn
+	// mutex guards pending and err
+	mutex sync.Mutex
+	// pending are the requests waiting for a response, in the order they were sent
+	pending []chan response
+	// done is closed when the reader stopped because of err
+	done chan struct{}
+	err  error
+	// outOfSync is set when a response arrived that no request was waiting for
+	outOfSync atomic.Bool
+}
+
+// response is a response to a request, or the error that prevented it
+type response struct {
+	message Message
+	err     error
+}
+
+func newConnection(conn net.Conn) *connection {
+	return &connection{
+		conn: conn,
+		done: make(chan struct{}),
+	}
+}
+
+// expect registers requests that are about to be sent, it fails when the connection is lost
+func (c *connection) expect(responses []chan response) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	c.pending = append(c.pending, responses...)
+	return nil
+}
+
+// deliver hands a response to the oldest request waiting for one, it returns false if no request is waiting
+func (c *connection) deliver(message Message) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.pending) == 0 {
+		return false
+	}
+
+	c.pending[0] <- response{message: message}
+	c.pending = c.pending[1:]
+	return true
+}
+
+// stop marks the connection as lost, failing all requests still waiting for a response
+func (c *connection) stop(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.err = err
+	for _, pending := range c.pending {
+		pending <- response{err: err}
 	}
-	return false, 0, 0
+	c.pending = nil
+	close(c.done)
+}
+
+// waiting returns the number of requests waiting for a response
+func (c *connection) waiting() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.pending)
 }
 
-func read(dazeus *DaZeus) (Message, error) {
+// readLoop reads all messages from the core until the connection is lost. Responses are handed to the requests
+// waiting for them, and events are queued for Listen.
+func (dazeus *DaZeus) readLoop(c *connection) {
 	for {
-		hasMessage, offset, messageLen := checkMessage(dazeus)
-		if hasMessage {
-			discard := make([]byte, offset)
-			bytesRead, err := dazeus.buffer.Read(discard)
+		msg, err := read(dazeus, c)
+		if err != nil {
+			// a message that can not be decoded might have been the response to the oldest request, so the responses
+			// can only be matched to the requests again when none are waiting
+			var frameErr *frameError
+			if errors.As(err, &frameErr) {
+				waiting := c.waiting()
+				if waiting == 0 {
+					dazeus.incoming.push(incoming{err: err, connection: c})
+					continue
+				}
+
+				err = fmt.Errorf("Malformed message from the core while %d request(s) waited for a response: %v", waiting, err)
+			}
 
-			if err != nil {
-				return nil, err
+			c.stop(err)
+			if !dazeus.reconnect {
+				dazeus.terminate()
 			}
+			dazeus.incoming.push(incoming{err: err, connection: c})
+			return
+		}
 
-			if bytesRead != offset {
-				return nil, errors.New("Could not read expected number of offset bytes")
+		if msg["event"] != nil {
+			evt, err := dazeus.receiveEvent(msg)
+			if err == nil && !dazeus.wantsEvent(evt) {
+				// the event was only needed by the library itself, queueing it would grow the queue forever when
+				// the plugin does not listen
+				continue
 			}
 
-			message := make([]byte, messageLen)
-			bytesRead, err = dazeus.buffer.Read(message)
+			dazeus.incoming.push(incoming{event: evt, err: err, connection: c})
+			continue
+		}
 
-			if err != nil {
-				return nil, err
-			}
+		if !c.deliver(msg) {
+			c.outOfSync.Store(true)
+			dazeus.logger.Printf("Protocol state out of sync, dropping response no request is waiting for: %v", msg)
+		}
+	}
+}
 
-			if bytesRead != messageLen {
-				return nil, errors.New("Could not read expected message from buffer")
-			}
+// read retrieves the next message from the core, answering any keepalive pings from the core on the way
+func read(dazeus *DaZeus, c *connection) (Message, error) {
+	for {
+		msg, err := readFrame(dazeus, c.conn)
+		if err != nil {
+			return nil, err
+		}
+
+		if msg["do"] != "ping" {
+			return msg, nil
+		}
+
+		dazeus.connMutex.Lock()
+		dazeus.lastPing = dazeus.clock.Now()
+		dazeus.connMutex.Unlock()
+
+		dazeus.logger.Print("Answering keepalive ping from core")
+		pong := Message{
+			"did":     "ping",
+			"success": true,
+		}
+		if msg["params"] != nil {
+			pong["params"] = msg["params"]
+		}
+
+		frame, err := encode(dazeus, pong)
+		if err != nil {
+			return nil, err
+		}
+
+		dazeus.writeMutex.Lock()
+		err = writeFrame(dazeus, c.conn, frame)
+		dazeus.writeMutex.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// readFrame reads a single framed message from the core
+func readFrame(dazeus *DaZeus, conn net.Conn) (Message, error) {
+	for {
+		hasMessage, offset, messageLen, err := checkMessage(dazeus)
+		if err != nil {
+			return nil, err
+		}
+
+		if hasMessage {
+			// checkMessage made sure the buffer holds the whole frame, so these return exactly the requested bytes
+			dazeus.buffer.Next(offset)
+			message := dazeus.buffer.Next(messageLen)
 
 			dazeus.logger.Printf("Received message from core: %s", message)
+			dazeus.stats.messagesReceived.Add(1)
 
 			msg := make(map[string]interface{})
-			err = json.Unmarshal(message, &msg)
+			err := json.Unmarshal(message, &msg)
 
 			if err != nil {
-				return nil, err
+				return nil, &frameError{err}
 			}
 
+			shrinkBuffer(dazeus)
 			return msg, nil
 		}
 
-		next := make([]byte, 1024)
-		bytesRead, err := dazeus.conn.Read(next)
+		// read straight into the free space of the buffer to avoid an extra allocation per read
+		dazeus.buffer.Grow(readSize)
+		next := dazeus.buffer.AvailableBuffer()
+		bytesRead, err := conn.Read(next[:cap(next)])
+		dazeus.stats.bytesReceived.Add(uint64(bytesRead))
 
 		if err != nil {
 			return nil, err
 		}
 
-		bytesWritten, err := dazeus.buffer.Write(next[0:bytesRead])
+		bytesWritten, err := dazeus.buffer.Write(next[:bytesRead])
 
 		if err != nil {
 			return nil, err
@@ -85,70 +327,92 @@ func read(dazeus *DaZeus) (Message, error) {
 	}
 }
 
-func write(dazeus *DaZeus, message Message) error {
+// encode frames a message for sending it to the core
+func encode(dazeus *DaZeus, message Message) ([]byte, error) {
 	bytes, err := json.Marshal(message)
 	dazeus.logger.Printf("Sending message to core: %s", bytes)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if dazeus.maxMessageSize > 0 && len(bytes) > dazeus.maxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds the maximum of %d bytes", ErrMessageTooLarge, len(bytes), dazeus.maxMessageSize)
 	}
 
 	msglen := []byte(strconv.Itoa(len(bytes)))
-	tosend := append(msglen, bytes...)
+	return append(msglen, bytes...), nil
+}
 
-	bytesWritten, err := dazeus.conn.Write(tosend)
+// writeFrame writes an encoded message to the core, the write mutex must be held
+func writeFrame(dazeus *DaZeus, conn net.Conn, tosend []byte) error {
+	// a single write may send only part of a large message, keep going until everything is sent
+	for len(tosend) > 0 {
+		bytesWritten, err := conn.Write(tosend)
+		dazeus.stats.bytesSent.Add(uint64(bytesWritten))
 
-	if err != nil {
-		return err
-	}
+		if err != nil {
+			return err
+		}
+
+		if bytesWritten == 0 {
+			return errors.New("Could not write complete message to socket")
+		}
 
-	if bytesWritten != len(tosend) {
-		return errors.New("Could not write complete message to socket")
+		tosend = tosend[bytesWritten:]
 	}
 
+	dazeus.stats.messagesSent.Add(1)
 	return nil
 }
 
-func waitForResponse(dazeus *DaZeus) (Message, error) {
-	for {
-		msg, err := read(dazeus)
-
+// request sends requests to the core, returning the channels their responses will be delivered on. The connection
+// they were sent on is returned too, also when sending failed.
+func request(dazeus *DaZeus, messages ...Message) (*connection, []chan response, error) {
+	frames := make([][]byte, len(messages))
+	for i, message := range messages {
+		frame, err := encode(dazeus, message)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		frames[i] = frame
+	}
 
-		if msg["event"] != nil {
-			dazeus.callDepth++
-			err = handleEvent(dazeus, msg)
-			dazeus.callDepth--
-
-			if err != nil {
-				return nil, err
-			}
+	dazeus.writeMutex.Lock()
+	defer dazeus.writeMutex.Unlock()
 
-			// check for buffered messages
-			if len(dazeus.responseQueue) > dazeus.callDepth {
-				msg = dazeus.responseQueue[0]
-				dazeus.responseQueue = dazeus.responseQueue[1:]
+	c := dazeus.connection.Load()
+	responses := make([]chan response, len(messages))
+	for i := range responses {
+		responses[i] = make(chan response, 1)
+	}
 
-				return msg, nil
-			}
-		} else {
-			if len(dazeus.responseQueue) == dazeus.callDepth {
-				return msg, nil
-			}
+	// register the requests before sending them, the responses might arrive before writing returns
+	err := c.expect(responses)
+	if err != nil {
+		return c, nil, err
+	}
 
-			// this one is for one call level above
-			dazeus.responseQueue = append(dazeus.responseQueue, msg)
+	for _, frame := range frames {
+		err = writeFrame(dazeus, c.conn, frame)
+		if err != nil {
+			// the framing is broken after a failed write, so the connection can not be used anymore
+			c.conn.Close()
+			return c, nil, err
 		}
 	}
+
+	return c, responses, nil
 }
 
-func waitForSuccessResponse(dazeus *DaZeus) (Message, error) {
-	response, err := waitForResponse(dazeus)
+// checkSuccess checks if a response of the core indicates success
+func checkSuccess(dazeus *DaZeus, response Message) (Message, error) {
+	if dazeus.isSuccess != nil {
+		if !dazeus.isSuccess(response) {
+			return nil, newServerError(response)
+		}
 
-	if err != nil {
-		return nil, err
+		return response, nil
 	}
 
 	if response["success"] == nil {
@@ -161,41 +425,215 @@ func waitForSuccessResponse(dazeus *DaZeus) (Message, error) {
 	}
 
 	if !success {
-		return nil, errors.New("Server responded with failure")
+		return nil, newServerError(response)
 	}
 
 	return response, nil
 }
 
-func writeForSuccessResponse(dazeus *DaZeus, message Message) (Message, error) {
-	err := write(dazeus, message)
+// writeForSuccessResponse sends a request to the core and waits for a successful response, giving up when the
+// context is done. The response of a request that was given up on is ignored when it arrives.
+func writeForSuccessResponse(ctx context.Context, dazeus *DaZeus, message Message) (Message, error) {
+	if dazeus.isClosed() {
+		return nil, ErrClosed
+	}
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	err := dazeus.checkPermitted(message)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := waitForSuccessResponse(dazeus)
+	resp, c, err := exchange(ctx, dazeus, message)
+	if err != nil && dazeus.isClosed() {
+		return nil, ErrClosed
+	}
+
+	if err == nil || !dazeus.canRecover(err) {
+		return resp, err
+	}
+
+	// the core went away while handling the request, reconnect and retry the request if that is safe
+	err = dazeus.reestablish(ctx, err, c)
 	if err != nil {
 		return nil, err
 	}
 
-	return resp, nil
+	if !isRetryable(message) {
+		return nil, ErrCoreRestarted
+	}
+
+	dazeus.logger.Printf("Retrying request after reconnecting: %v", message)
+	resp, _, err = exchange(ctx, dazeus, message)
+	return resp, err
 }
 
-func waitForEvent(dazeus *DaZeus) error {
-	msg, err := read(dazeus)
+// exchange sends a request to the core and waits for a successful response, returning the connection it used
+func exchange(ctx context.Context, dazeus *DaZeus, message Message) (Message, *connection, error) {
+	c, responses, err := request(dazeus, message)
+	if err != nil {
+		dazeus.recordError(err)
+		return nil, c, err
+	}
 
+	var resp response
+	select {
+	case resp = <-responses[0]:
+	case <-ctx.Done():
+		return nil, c, ctx.Err()
+	}
+
+	if resp.err != nil {
+		dazeus.recordError(resp.err)
+		return nil, c, resp.err
+	}
+
+	msg, err := checkSuccess(dazeus, resp.message)
 	if err != nil {
-		return err
+		dazeus.recordError(err)
+		return nil, c, err
+	}
+
+	return msg, c, nil
+}
+
+// writeForSuccessResponses sends several requests at once and then waits for all responses, which saves a round trip
+// per request. The responses and errors are in the order of the requests. An error is returned when the connection
+// failed.
+func writeForSuccessResponses(ctx context.Context, dazeus *DaZeus, messages []Message) ([]Message, []error, error) {
+	if dazeus.isClosed() {
+		return nil, nil, ErrClosed
 	}
 
-	if msg["event"] == nil {
-		return errors.New("Unexpected non-event message retrieved")
+	for _, message := range messages {
+		err := dazeus.checkPermitted(message)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	err = handleEvent(dazeus, msg)
+	_, pending, err := request(dazeus, messages...)
 	if err != nil {
-		return err
+		dazeus.recordError(err)
+		return nil, nil, err
 	}
 
-	return nil
+	responses := make([]Message, len(messages))
+	errs := make([]error, len(messages))
+	for i, ch := range pending {
+		var resp response
+		select {
+		case resp = <-ch:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+
+		if resp.err != nil {
+			dazeus.recordError(resp.err)
+			return nil, nil, resp.err
+		}
+
+		responses[i], errs[i] = checkSuccess(dazeus, resp.message)
+	}
+
+	return responses, errs, nil
+}
+
+// writeForOptionalResponse writes a request the core might not support, a failure response results in ErrNotSupported
+func writeForOptionalResponse(ctx context.Context, dazeus *DaZeus, message Message) (Message, error) {
+	resp, err := writeForSuccessResponse(ctx, dazeus, message)
+	if errors.Is(err, errFailureResponse) {
+		return nil, ErrNotSupported
+	}
+
+	return resp, err
+}
+
+// waitForWaiter waits until the waiter received its event, the timeout expired or the context is done
+func waitForWaiter(ctx context.Context, dazeus *DaZeus, waiter *eventWaiter, timeout time.Duration) (Event, error) {
+	c := dazeus.connection.Load()
+
+	select {
+	case evt := <-waiter.event:
+		return evt, nil
+	case <-waiter.closed:
+		return Event{}, ErrClosed
+	case <-c.done:
+		dazeus.removeWaiter(waiter)
+		if dazeus.isClosed() {
+			return Event{}, ErrClosed
+		}
+		return Event{}, c.err
+	case <-dazeus.clock.After(timeout):
+		dazeus.removeWaiter(waiter)
+		return Event{}, ErrTimeout
+	case <-ctx.Done():
+		dazeus.removeWaiter(waiter)
+		return Event{}, ctx.Err()
+	}
+}
+
+// incoming is an event, or an error reading from a connection, passed on from the reader to Listen
+type incoming struct {
+	event      Event
+	err        error
+	connection *connection
+}
+
+// incomingQueue queues everything the reader passes on to Listen, it grows as needed so the reader never blocks on
+// a slow event handler. Only events that have a listener are queued.
+type incomingQueue struct {
+	mutex  sync.Mutex
+	items  []incoming
+	signal chan struct{}
+}
+
+func newIncomingQueue() *incomingQueue {
+	return &incomingQueue{
+		signal: make(chan struct{}, 1),
+	}
+}
+
+// push adds an item to the end of the queue
+func (queue *incomingQueue) push(item incoming) {
+	queue.mutex.Lock()
+	queue.items = append(queue.items, item)
+	queue.mutex.Unlock()
+
+	select {
+	case queue.signal <- struct{}{}:
+	default:
+	}
+}
+
+// pop removes the first item from the queue, waiting for one if the queue is empty
+func (queue *incomingQueue) pop(ctx context.Context) (incoming, error) {
+	for {
+		queue.mutex.Lock()
+		if len(queue.items) > 0 {
+			item := queue.items[0]
+			queue.items[0] = incoming{}
+			queue.items = queue.items[1:]
+			queue.mutex.Unlock()
+			return item, nil
+		}
+		queue.mutex.Unlock()
+
+		select {
+		case <-queue.signal:
+		case <-ctx.Done():
+			return incoming{}, ctx.Err()
+		}
+	}
+}
+
+// len returns the number of queued items
+func (queue *incomingQueue) len() int {
+	queue.mutex.Lock()
+	defer queue.mutex.Unlock()
+
+	return len(queue.items)
 }
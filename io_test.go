@@ -0,0 +1,211 @@
+package dazeus
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// frame encodes a payload with its length prefix, like the core does
+func frame(payload string) string {
+	return strconv.Itoa(len(payload)) + payload
+}
+
+func TestUndecodableResponseFailsPendingRequest(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		buffer := make([]byte, 4096)
+		_, err := server.Read(buffer)
+		if err != nil {
+			return
+		}
+
+		server.Write([]byte(frame("{not json}")))
+		server.Write([]byte(frame(`{"got":"config","success":true,"value":"second"}`)))
+		server.Write([]byte(frame(`{"got":"config","success":true,"value":"third"}`)))
+	}()
+
+	dazeus := NewWithConn(client, nil)
+	defer dazeus.Close()
+
+	value, err := dazeus.GetConfig("first", "plugin")
+	if err == nil {
+		t.Fatalf("GetConfig returned %q for a response that could not be decoded", value)
+	}
+
+	value, err = dazeus.GetConfig("next", "plugin")
+	if err == nil {
+		t.Fatalf("GetConfig returned %q on a connection that is out of sync", value)
+	}
+}
+
+// trickleConn is a connection that writes and reads at most one byte at a time
+type trickleConn struct {
+	net.Conn
+	written bytes.Buffer
+	toRead  []byte
+}
+
+func (conn *trickleConn) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	conn.written.WriteByte(p[0])
+	return 1, nil
+}
+
+func (conn *trickleConn) Read(p []byte) (int, error) {
+	if len(conn.toRead) == 0 {
+		return 0, io.EOF
+	}
+
+	p[0] = conn.toRead[0]
+	conn.toRead = conn.toRead[1:]
+	return 1, nil
+}
+
+func newTestDaZeus() *DaZeus {
+	return &DaZeus{
+		logger:          log.New(io.Discard, "", 0),
+		bufferRetention: defaultBufferRetention,
+		maxMessageSize:  DefaultMaxMessageSize,
+	}
+}
+
+func TestWriteFrameShortWrites(t *testing.T) {
+	dazeus := newTestDaZeus()
+	payload := strings.Repeat("x", 10000)
+	conn := &trickleConn{}
+
+	err := writeFrame(dazeus, conn, []byte(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.written.String() != payload {
+		t.Errorf("wrote %d bytes, want %d intact bytes", conn.written.Len(), len(payload))
+	}
+}
+
+func TestReadFrameTinyChunks(t *testing.T) {
+	dazeus := newTestDaZeus()
+	conn := &trickleConn{
+		toRead: []byte(frame(`{"got":"nick","success":true,"nick":"bot"}`) + "\n" + frame(`{"event":"JOIN","params":[]}`)),
+	}
+
+	msg, err := readFrame(dazeus, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg["nick"] != "bot" {
+		t.Errorf("first message is %v", msg)
+	}
+
+	msg, err = readFrame(dazeus, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg["event"] != "JOIN" {
+		t.Errorf("second message is %v", msg)
+	}
+
+	_, err = readFrame(dazeus, conn)
+	if err != io.EOF {
+		t.Errorf("got %v after the last message, want io.EOF", err)
+	}
+}
+
+// repeatConn is a connection that sends the same data over and over
+type repeatConn struct {
+	net.Conn
+	data   []byte
+	offset int
+}
+
+func (conn *repeatConn) Read(p []byte) (int, error) {
+	n := copy(p, conn.data[conn.offset:])
+	conn.offset = (conn.offset + n) % len(conn.data)
+	return n, nil
+}
+
+func BenchmarkReadFrame(b *testing.B) {
+	for _, size := range []int{4 * 1024, 64 * 1024} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			names := strings.Repeat("nick ", size/5)
+			dazeus := newTestDaZeus()
+			conn := &repeatConn{data: []byte(frame(`{"event":"NAMES","params":["net","server","#chan","` + names + `"]}`))}
+
+			b.SetBytes(int64(len(conn.data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := readFrame(dazeus, conn)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestResetProtocolState(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	requests := make(chan struct{})
+	go func() {
+		buffer := make([]byte, 4096)
+		_, err := server.Read(buffer)
+		if err != nil {
+			return
+		}
+		requests <- struct{}{}
+	}()
+
+	dazeus := NewWithConn(client, nil)
+	defer dazeus.Close()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := dazeus.GetConfig("slow", "plugin")
+		result <- err
+	}()
+	<-requests
+
+	// the request is slow, but nothing is out of sync
+	if dazeus.ResetProtocolState() {
+		t.Fatal("ResetProtocolState dropped a healthy connection")
+	}
+
+	// an extra response takes the place of the one for the request, which then arrives when nothing waits for it
+	_, err := server.Write([]byte(frame(`{"got":"nick","success":true,"nick":"bot"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-result
+	_, err = server.Write([]byte(frame(`{"got":"config","success":true,"value":"slow"}`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the reader to drop the extra response
+	for !dazeus.connection.Load().outOfSync.Load() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if !dazeus.ResetProtocolState() {
+		t.Fatal("ResetProtocolState did not drop a connection that is out of sync")
+	}
+	select {
+	case <-dazeus.Done():
+	case <-time.After(5 * time.Second):
+		t.Error("the connection is still open after resetting the protocol state")
+	}
+}
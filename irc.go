@@ -0,0 +1,128 @@
+package dazeus
+
+import (
+	"net"
+	"strings"
+)
+
+// CaseMapping determines how the case of IRC identifiers such as commands is compared
+type CaseMapping int
+
+const (
+	// CaseSensitive compares identifiers exactly
+	CaseSensitive CaseMapping = iota
+	// CaseASCII ignores the case of the ASCII letters
+	CaseASCII
+	// CaseRFC1459 ignores case like IRC servers do, where []\~ are the uppercase variants of {}|^
+	CaseRFC1459
+)
+
+// Fold returns the lowercase form of the identifier according to the case mapping
+func (mapping CaseMapping) Fold(s string) string {
+	if mapping == CaseSensitive {
+		return s
+	}
+
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		case mapping != CaseRFC1459:
+			return r
+		case r == '[':
+			return '{'
+		case r == ']':
+			return '}'
+		case r == '\\':
+			return '|'
+		case r == '~':
+			return '^'
+		}
+		return r
+	}, s)
+}
+
+// Equal indicates if two identifiers are the same according to the case mapping
+func (mapping CaseMapping) Equal(a string, b string) bool {
+	return mapping.Fold(a) == mapping.Fold(b)
+}
+
+// ParseHostmask splits a hostmask of the form nick!user@host into its parts, ok is false when the mask lacks the
+// user or host part. When there is no user part the nick is everything before the host part.
+func ParseHostmask(mask string) (nick string, user string, host string, ok bool) {
+	nick, rest, hasUser := strings.Cut(mask, "!")
+	if !hasUser {
+		nick, host, _ = strings.Cut(mask, "@")
+		return nick, "", host, false
+	}
+
+	user, host, hasHost := strings.Cut(rest, "@")
+	return nick, user, host, hasHost && nick != ""
+}
+
+// MatchMask indicates if a hostmask matches a pattern, where * matches any number of characters and ? matches a
+// single character. Case is ignored like IRC servers do.
+func MatchMask(mask string, pattern string) bool {
+	return wildcardMatch(CaseRFC1459.Fold(pattern), CaseRFC1459.Fold(mask))
+}
+
+// BanStyle determines which parts of a hostmask a ban mask matches on
+type BanStyle int
+
+const (
+	// BanHost bans everyone from the same host, like *!*@host
+	BanHost BanStyle = iota
+	// BanUser bans the same user on the same host, like *!*user@host
+	BanUser
+	// BanNick bans the nick regardless of where it connects from, like nick!*@*
+	BanNick
+	// BanDomain bans everyone from the same domain or IPv4 subnet, like *!*@*.example.com or *!*@192.0.2.*
+	BanDomain
+)
+
+// BanMask builds a ban mask in some style for a hostmask of the form nick!user@host, such as the sender of an event.
+// The resulting mask always matches the hostmask with MatchMask. The ident prefix ~ of unverified users is replaced
+// by a wildcard, so the ban still matches when identd is enabled later. Parts missing from the hostmask become
+// wildcards.
+func BanMask(hostmask string, style BanStyle) string {
+	nick, user, host, _ := ParseHostmask(hostmask)
+	if host == "" {
+		host = "*"
+	}
+
+	switch style {
+	case BanUser:
+		user = strings.TrimLeft(user, "~")
+		if user == "" {
+			return "*!*@" + host
+		}
+		return "*!*" + user + "@" + host
+	case BanNick:
+		if nick == "" {
+			nick = "*"
+		}
+		return nick + "!*@*"
+	case BanDomain:
+		return "*!*@" + banDomain(host)
+	}
+
+	return "*!*@" + host
+}
+
+// banDomain generalizes a host to its domain, or to its subnet for IPv4 addresses
+func banDomain(host string) string {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.To4() == nil {
+			return host
+		}
+
+		return host[:strings.LastIndex(host, ".")+1] + "*"
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+
+	return "*." + strings.Join(labels[1:], ".")
+}
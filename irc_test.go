@@ -0,0 +1,97 @@
+package dazeus
+
+import "testing"
+
+func TestParseHostmask(t *testing.T) {
+	tests := []struct {
+		mask string
+		nick string
+		user string
+		host string
+		ok   bool
+	}{
+		{"nick!user@host", "nick", "user", "host", true},
+		{"nick!~user@sub.example.com", "nick", "~user", "sub.example.com", true},
+		{"nick!user@host@odd", "nick", "user", "host@odd", true},
+		{"nick@host", "nick", "", "host", false},
+		{"nick!user", "nick", "user", "", false},
+		{"nick", "nick", "", "", false},
+		{"!user@host", "", "user", "host", false},
+		{"", "", "", "", false},
+	}
+
+	for _, test := range tests {
+		nick, user, host, ok := ParseHostmask(test.mask)
+		if nick != test.nick || user != test.user || host != test.host || ok != test.ok {
+			t.Errorf("ParseHostmask(%q) = %q, %q, %q, %v, want %q, %q, %q, %v", test.mask, nick, user, host, ok,
+				test.nick, test.user, test.host, test.ok)
+		}
+	}
+}
+
+func TestMatchMask(t *testing.T) {
+	tests := []struct {
+		mask    string
+		pattern string
+		match   bool
+	}{
+		{"nick!user@host", "nick!user@host", true},
+		{"nick!user@host", "*", true},
+		{"nick!user@host", "*!*@host", true},
+		{"nick!user@host", "*!*@other", false},
+		{"nick!user@sub.example.com", "*!*@*.example.com", true},
+		{"nick!user@example.com", "*!*@*.example.com", false},
+		{"nick!user@host", "nic?!user@host", true},
+		{"nick!user@host", "ni?!user@host", false},
+		{"nick!user@host", "????!*", true},
+		{"nick!user@host", "*user*", true},
+		{"nick!user@host", "*!*@", false},
+		{"nick", "nick", true},
+		{"nick", "n*", true},
+		{"nick@host", "*!*@host", false},
+		{"nick@host", "*@host", true},
+		{"", "*", true},
+		{"", "?", false},
+		{"NICK!User@HOST", "nick!user@host", true},
+		{"nick[away]!user@host", "NICK{AWAY}!*@*", true},
+		{"nick\\x!user@host", "nick|x!*@*", true},
+		{"nick~!user@host", "nick^!*@*", true},
+		{"nïck!user@host", "n?ck!*@*", true},
+	}
+
+	for _, test := range tests {
+		if match := MatchMask(test.mask, test.pattern); match != test.match {
+			t.Errorf("MatchMask(%q, %q) = %v, want %v", test.mask, test.pattern, match, test.match)
+		}
+	}
+}
+
+func TestWildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"", "", true},
+		{"", "a", false},
+		{"*", "", true},
+		{"**", "abc", true},
+		{"a*", "abc", true},
+		{"*c", "abc", true},
+		{"*b*", "abc", true},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b", "abX", false},
+		{"?", "", false},
+		{"???", "abc", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"*?", "a", true},
+		{"A", "a", false},
+	}
+
+	for _, test := range tests {
+		if match := wildcardMatch(test.pattern, test.s); match != test.match {
+			t.Errorf("wildcardMatch(%q, %q) = %v, want %v", test.pattern, test.s, match, test.match)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package dazeus
+
+// Mute suppresses all messages, actions and notices the bot sends to a channel in some network, without leaving
+// the channel. Events from the channel are still handled. Suppressed messages are logged.
+func (dazeus *DaZeus) Mute(network string, channel string) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	if dazeus.mutes == nil {
+		dazeus.mutes = make(map[string]bool)
+	}
+	dazeus.mutes[muteKey(network, channel)] = true
+}
+
+// Unmute lets the bot talk again in a channel muted with Mute
+func (dazeus *DaZeus) Unmute(network string, channel string) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	delete(dazeus.mutes, muteKey(network, channel))
+}
+
+// IsMuted indicates if the bot is muted in a channel in some network
+func (dazeus *DaZeus) IsMuted(network string, channel string) bool {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	return dazeus.mutes[muteKey(network, channel)]
+}
+
+func muteKey(network string, channel string) string {
+	return network + "/" + CaseRFC1459.Fold(channel)
+}
@@ -0,0 +1,90 @@
+package dazeus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNotPermitted is returned when the core does not allow the plugin to make a request
+var ErrNotPermitted = errors.New("Request not permitted for this plugin")
+
+// fetchAllowedOperations asks the core which requests the plugin may make. Cores that do not restrict plugins, or
+// can not tell, leave the allowed operations unknown.
+func (dazeus *DaZeus) fetchAllowedOperations() error {
+	resp, err := writeForOptionalResponse(context.Background(), dazeus, map[string]interface{}{
+		"get": "allowed_operations",
+	})
+
+	if err == ErrNotSupported {
+		dazeus.logger.Print("Core does not report the allowed operations, not checking requests")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if resp["operations"] == nil {
+		return nil
+	}
+
+	operations, err := makeStringArray(resp["operations"])
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(operations))
+	for _, operation := range operations {
+		allowed[operation] = true
+	}
+
+	dazeus.settingsMutex.Lock()
+	dazeus.allowedOperations = allowed
+	dazeus.settingsMutex.Unlock()
+
+	return nil
+}
+
+// AllowedOperations returns the requests the core allows the plugin to make, such as "message" or "property", ok is
+// false when they are unknown, because checking them was not enabled with WithOperationCheck or the core does not
+// report them.
+func (dazeus *DaZeus) AllowedOperations() (operations []string, ok bool) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	if dazeus.allowedOperations == nil {
+		return nil, false
+	}
+
+	operations = make([]string, 0, len(dazeus.allowedOperations))
+	for operation := range dazeus.allowedOperations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	return operations, true
+}
+
+// checkPermitted returns ErrNotPermitted when the core is known not to allow the request
+func (dazeus *DaZeus) checkPermitted(message Message) error {
+	dazeus.settingsMutex.Lock()
+	allowed := dazeus.allowedOperations
+	dazeus.settingsMutex.Unlock()
+
+	if allowed == nil {
+		return nil
+	}
+
+	operation, ok := message["do"].(string)
+	if !ok {
+		operation, ok = message["get"].(string)
+	}
+
+	if !ok || allowed[operation] {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrNotPermitted, operation)
+}
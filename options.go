@@ -0,0 +1,226 @@
+package dazeus
+
+import (
+	"io"
+	"log"
+	"time"
+)
+
+// Option configures optional behaviour of a connection to the DaZeus core
+type Option func(*DaZeus)
+
+// WithLogger makes the connection log to the given logger instead of the default logger
+func WithLogger(logger *log.Logger) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.logger = logger
+	}
+}
+
+// WithPluginName sets the name of the plugin, which is also used as the prefix of the default logger
+func WithPluginName(name string) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.pluginName = name
+	}
+}
+
+// WithHandshake makes the plugin identify itself to the core with a name and version right after connecting, and
+// again after reconnecting, so it shows up in the admin listings of the core. Connecting fails when the core rejects
+// the handshake. The name is also used as the plugin name, unless WithPluginName sets another one.
+func WithHandshake(name string, version string) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.handshake = &handshake{name, version}
+		if dazeus.pluginName == "" {
+			dazeus.pluginName = name
+		}
+	}
+}
+
+// withLogOutput configures where the default logger writes to
+func withLogOutput(output io.Writer, flags int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.logOutput = output
+		dazeus.logFlags = flags
+	}
+}
+
+// WithPropertyNamespace transparently prefixes the keys of all properties with the given namespace and a dot,
+// so plugins sharing the property store don't overwrite each other's properties. When the prefix is empty the
+// plugin name is used as the namespace.
+func WithPropertyNamespace(prefix string) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.namespace = prefix
+		dazeus.namespaceProperties = true
+	}
+}
+
+// WithRateLimit limits how fast messages, notices, actions and CTCPs are sent: after an initial burst of messages
+// only one message is sent per interval, so the bot does not trip the flood protection of IRC servers.
+func WithRateLimit(interval time.Duration, burst int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.limiter = newRateLimiter(interval, burst)
+	}
+}
+
+// WithCommandCaseMapping sets how commands are matched to command subscriptions, for example ignoring case so
+// `!Weather` also triggers a handler for `weather`. By default commands are matched exactly.
+func WithCommandCaseMapping(mapping CaseMapping) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.commandCase = mapping
+	}
+}
+
+// WithBufferRetention sets the capacity the read buffer may keep after processing a large message. Once the buffer
+// has grown beyond this size it is replaced by a smaller one as soon as it is mostly empty again. A size of zero or
+// less keeps the buffer at whatever size it has grown to.
+func WithBufferRetention(size int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.bufferRetention = size
+	}
+}
+
+// WithReconnect makes Listen reconnect to the core when the connection is lost, restoring all subscriptions.
+// After maxAttempts consecutive failed attempts Listen gives up and returns ErrReconnectExhausted, a maximum of zero
+// or less keeps trying forever.
+func WithReconnect(maxAttempts int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.reconnect = true
+		dazeus.maxReconnects = maxAttempts
+	}
+}
+
+// WithReconnectHandler sets a function called after the connection was re-established and the subscriptions were
+// restored, to fetch again any state the plugin keeps about the core. Requests it makes while the connection is lost
+// again fail instead of reconnecting.
+func WithReconnectHandler(handler func(*DaZeus)) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.onReconnect = handler
+	}
+}
+
+// WithPanicHandler sets a function called with the event and the recovered value when an event handler panics. The
+// panic is always logged, and the other handlers still get the event.
+func WithPanicHandler(handler func(evt Event, recovered interface{})) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.onPanic = handler
+	}
+}
+
+// WithStopOnHandlerError makes Listen stop and return the error when a handler registered with SubscribeWithError or
+// SubscribeCommandWithError fails, instead of only logging the error.
+func WithStopOnHandlerError() Option {
+	return func(dazeus *DaZeus) {
+		dazeus.stopOnHandlerError = true
+	}
+}
+
+// WithStatusPrefixes sets the prefixes the server of a network supports for status messages, see SetStatusPrefixes
+func WithStatusPrefixes(network string, prefixes string) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.setStatusPrefixes(network, prefixes)
+	}
+}
+
+// WithTargetRateLimit limits how fast messages are sent to a single channel or user, in addition to the global limit
+// set by WithRateLimit. A burst of messages to one target then does not delay messages to other targets.
+func WithTargetRateLimit(interval time.Duration, burst int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.targetLimiters = newTargetLimiters(interval, burst)
+	}
+}
+
+// WithSuccessFunc replaces how responses of the core are judged, by default a response is successful when its
+// success field is true. Responses the function rejects make the request fail.
+func WithSuccessFunc(isSuccess func(Message) bool) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.isSuccess = isSuccess
+	}
+}
+
+// WithStateTracking keeps track of the nick of the bot and the channels it is in, including their members, by
+// fetching them on connect and following the events that change them.
+func WithStateTracking() Option {
+	return func(dazeus *DaZeus) {
+		dazeus.trackState = true
+	}
+}
+
+// WithMaxMessageSize sets the maximum size in bytes of a message exchanged with the core. Larger messages are refused
+// with ErrMessageTooLarge instead of being sent, when the core announces a larger message the connection is dropped
+// with ErrMessageTooLarge instead of buffering it. A size of zero or less disables the check.
+func WithMaxMessageSize(size int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.maxMessageSize = size
+	}
+}
+
+// WithHandlerTimeout logs event handlers that run longer than the timeout. When abandon is set the library stops
+// waiting for such a handler and continues delivering events, leaving the handler running in the background.
+func WithHandlerTimeout(timeout time.Duration, abandon bool) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.handlerTimeout = timeout
+		dazeus.abandonHandlers = abandon
+	}
+}
+
+// WithMaxLineLength sets the maximum length in bytes of a single message sent to IRC, used when replies are cut
+// off or split into multiple lines.
+func WithMaxLineLength(length int) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.maxLineLength = length
+	}
+}
+
+// WithSkipMalformedFrames makes Listen skip messages from the core that can not be decoded instead of stopping,
+// only errors of the connection itself stop it then. Skipped messages are counted by SkippedFrames.
+func WithSkipMalformedFrames() Option {
+	return func(dazeus *DaZeus) {
+		dazeus.skipMalformed = true
+	}
+}
+
+// WithPasteBackend sets the paste service used by Event.ReplyViaPaste
+func WithPasteBackend(backend PasteBackend) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.pasteBackend = backend
+	}
+}
+
+// WithAuditSink sets where Event.ReplyLogged records the interactions of the bot
+func WithAuditSink(sink AuditSink) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.auditSink = sink
+	}
+}
+
+// WithDuplicateSuppression suppresses sending a message to a channel when the same message was the last one sent to
+// that channel less than the window ago, for example because a handler ran twice. Suppressed messages are logged.
+func WithDuplicateSuppression(window time.Duration) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.duplicateWindow = window
+	}
+}
+
+// WithOperationCheck asks the core on connect which requests the plugin may make, after which requests the core
+// would reject are refused with ErrNotPermitted without sending them. Nothing is checked when the core does not
+// report the allowed operations.
+func WithOperationCheck() Option {
+	return func(dazeus *DaZeus) {
+		dazeus.checkOperations = true
+	}
+}
+
+// WithClock replaces the clock used for rate limits, timeouts and timestamps, for example to test time-dependent
+// behavior of a plugin deterministically. Deadlines on the connection itself always use the actual time.
+func WithClock(clock Clock) Option {
+	return func(dazeus *DaZeus) {
+		dazeus.clock = clock
+	}
+}
+
+// WithUnknownEventLogging logs every event type that is not one of the known event types the first time it is
+// received, to discover events the core sends that this library does not know about yet.
+func WithUnknownEventLogging() Option {
+	return func(dazeus *DaZeus) {
+		dazeus.logUnknownEvents = true
+	}
+}
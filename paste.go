@@ -0,0 +1,68 @@
+package dazeus
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PasteBackend uploads text to a paste service
+type PasteBackend interface {
+	// Upload stores the text and returns the URL where it can be read
+	Upload(text string) (string, error)
+}
+
+// HTTPPasteBackend uploads text by posting it as a form field to a paste service that responds with the URL of the
+// paste, such as sprunge.us (field "sprunge") or ix.io (field "f:1").
+type HTTPPasteBackend struct {
+	// URL is where the form is posted to
+	URL string
+	// Field is the name of the form field containing the text
+	Field string
+	// Client is used to post the form, http.DefaultClient is used when it is nil
+	Client *http.Client
+}
+
+// Upload posts the text to the paste service
+func (backend *HTTPPasteBackend) Upload(text string) (string, error) {
+	client := backend.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.PostForm(backend.URL, url.Values{backend.Field: {text}})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("Paste service responded with %s", resp.Status)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
+
+// ReplyViaPaste uploads the text to the paste backend and replies with the URL of the paste. Without a paste backend,
+// or when the upload fails, it replies with the text cut off at the maximum line length instead.
+func (event *Event) ReplyViaPaste(text string) error {
+	backend := event.DaZeus.pasteBackend
+	if backend == nil {
+		return event.ReplyTruncated(text, false)
+	}
+
+	link, err := backend.Upload(text)
+	if err != nil {
+		event.DaZeus.logger.Printf("Could not upload paste: %v", err)
+		return event.ReplyTruncated(text, false)
+	}
+
+	return event.Reply(link, false)
+}
@@ -0,0 +1,106 @@
+package dazeus
+
+import (
+	"errors"
+	"sync"
+)
+
+// Pool manages several connections to the core, so requests can be sent in parallel instead of one by one over a
+// single connection. The first connection is the primary connection, which is used for subscriptions and listening
+// for events so every event is only delivered once. Requests are spread over the other connections.
+type Pool struct {
+	mutex   sync.Mutex
+	members []*poolMember
+	next    int
+}
+
+// poolMember is a single connection in a pool
+type poolMember struct {
+	dazeus *DaZeus
+	// inFlight is the number of functions using the connection
+	inFlight int
+}
+
+// NewPool opens size connections to the core, all configured with the same options
+func NewPool(connectionString string, size int, options ...Option) (*Pool, error) {
+	if size < 1 {
+		return nil, errors.New("A pool needs at least one connection")
+	}
+
+	pool := &Pool{
+		members: make([]*poolMember, 0, size),
+	}
+
+	for i := 0; i < size; i++ {
+		dazeus, err := Connect(connectionString, options...)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+
+		pool.members = append(pool.members, &poolMember{dazeus: dazeus})
+	}
+
+	return pool, nil
+}
+
+// Primary returns the primary connection, which should be used to subscribe to and listen for events
+func (pool *Pool) Primary() *DaZeus {
+	return pool.members[0].dazeus
+}
+
+// Do runs the function with the connection of the pool that the fewest functions are using. Functions run
+// concurrently, also when they get the same connection. The primary connection is only used when the pool has no
+// other connections.
+func (pool *Pool) Do(fn func(*DaZeus) error) error {
+	member := pool.acquire()
+	defer pool.release(member)
+
+	return fn(member.dazeus)
+}
+
+// acquire picks the connection with the fewest functions using it, going round-robin over connections that are used
+// equally
+func (pool *Pool) acquire() *poolMember {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	candidates := pool.members
+	if len(candidates) > 1 {
+		candidates = candidates[1:]
+	}
+
+	var best *poolMember
+	for i := range candidates {
+		member := candidates[(pool.next+i)%len(candidates)]
+		if best == nil || member.inFlight < best.inFlight {
+			best = member
+		}
+	}
+
+	pool.next = (pool.next + 1) % len(candidates)
+	best.inFlight++
+
+	return best
+}
+
+// release marks that a function is done with a connection
+func (pool *Pool) release(member *poolMember) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	member.inFlight--
+}
+
+// Close closes all connections of the pool
+func (pool *Pool) Close() error {
+	var errs []error
+	for _, member := range pool.members {
+		err := member.dazeus.Close()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,104 @@
+package dazeus_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dazeus/dazeus-go"
+	"github.com/dazeus/dazeus-go/dazeustest"
+)
+
+// startDo runs a function in the pool that blocks until release is closed, it returns once the function started
+func startDo(t *testing.T, pool *dazeus.Pool, wg *sync.WaitGroup, used chan<- *dazeus.DaZeus, release <-chan struct{}) {
+	t.Helper()
+
+	started := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		pool.Do(func(client *dazeus.DaZeus) error {
+			used <- client
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the function did not start while other functions were running")
+	}
+}
+
+func TestPoolSpreadsFunctions(t *testing.T) {
+	core, err := dazeustest.NewFakeCore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.Close()
+
+	pool, err := dazeus.NewPool(core.ConnectionString(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	used := make(chan *dazeus.DaZeus, 4)
+	release := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		startDo(t, pool, &wg, used, release)
+	}
+	close(release)
+	wg.Wait()
+	close(used)
+
+	counts := make(map[*dazeus.DaZeus]int)
+	for client := range used {
+		counts[client]++
+	}
+
+	if counts[pool.Primary()] != 0 {
+		t.Errorf("the primary connection was used %d times", counts[pool.Primary()])
+	}
+	if len(counts) != 2 {
+		t.Fatalf("functions ran on %d connections, want 2", len(counts))
+	}
+	for _, count := range counts {
+		if count != 2 {
+			t.Errorf("functions per connection = %v, want 2 each", counts)
+			break
+		}
+	}
+}
+
+func TestPoolRunsFunctionsConcurrentlyOnOneConnection(t *testing.T) {
+	core, err := dazeustest.NewFakeCore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer core.Close()
+
+	pool, err := dazeus.NewPool(core.ConnectionString(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	used := make(chan *dazeus.DaZeus, 2)
+	release := make(chan struct{})
+	defer wg.Wait()
+	defer close(release)
+
+	// both functions get the only connection besides the primary one, the second must not wait for the first
+	startDo(t, pool, &wg, used, release)
+	startDo(t, pool, &wg, used, release)
+
+	if first, second := <-used, <-used; first != second || first == pool.Primary() {
+		t.Error("the functions did not both run on the connection besides the primary one")
+	}
+}
@@ -0,0 +1,130 @@
+package dazeus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultBroadcastInterval is the pause between broadcast messages when no rate limit is configured
+	defaultBroadcastInterval = time.Second
+	// defaultBroadcastBurst is the number of broadcast messages sent without pausing when no rate limit is configured
+	defaultBroadcastBurst = 4
+)
+
+// rateLimiter is a token bucket that limits how fast messages are sent
+type rateLimiter struct {
+	mutex    sync.Mutex
+	waiting  atomic.Int32
+	interval time.Duration
+	burst    int
+	tokens   float64
+	last     time.Time
+}
+
+// newRateLimiter creates a rate limiter allowing a burst of messages, after which one message is allowed per interval
+func newRateLimiter(interval time.Duration, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		interval: interval,
+		burst:    burst,
+		tokens:   float64(burst),
+	}
+}
+
+// wait blocks until a message may be sent, telling time with the clock, or until the context is done
+func (limiter *rateLimiter) wait(ctx context.Context, clock Clock) error {
+	limiter.waiting.Add(1)
+	defer limiter.waiting.Add(-1)
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	now := clock.Now()
+	if limiter.last.IsZero() {
+		limiter.last = now
+	}
+	if limiter.interval > 0 {
+		limiter.tokens += float64(now.Sub(limiter.last)) / float64(limiter.interval)
+	}
+	if limiter.tokens > float64(limiter.burst) || limiter.interval <= 0 {
+		limiter.tokens = float64(limiter.burst)
+	}
+	limiter.last = now
+
+	if limiter.tokens >= 1 {
+		limiter.tokens--
+		return nil
+	}
+
+	delay := time.Duration((1 - limiter.tokens) * float64(limiter.interval))
+	select {
+	case <-clock.After(delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	limiter.tokens = 0
+	limiter.last = now.Add(delay)
+	return nil
+}
+
+// targetLimiters keeps a separate rate limiter for every target messages are sent to
+type targetLimiters struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	burst    int
+	limiters map[string]*rateLimiter
+}
+
+// newTargetLimiters creates per-target rate limiters with the given interval and burst
+func newTargetLimiters(interval time.Duration, burst int) *targetLimiters {
+	return &targetLimiters{
+		interval: interval,
+		burst:    burst,
+		limiters: make(map[string]*rateLimiter),
+	}
+}
+
+// wait blocks until a message may be sent to the target in some network
+func (targets *targetLimiters) wait(ctx context.Context, clock Clock, network string, target string) error {
+	key := network + "/" + target
+
+	targets.mutex.Lock()
+	limiter, ok := targets.limiters[key]
+	if !ok {
+		limiter = newRateLimiter(targets.interval, targets.burst)
+		targets.limiters[key] = limiter
+	}
+	targets.mutex.Unlock()
+
+	return limiter.wait(ctx, clock)
+}
+
+// queueDepths adds the number of messages waiting for each target to the depths
+func (targets *targetLimiters) queueDepths(depths map[string]int) {
+	targets.mutex.Lock()
+	defer targets.mutex.Unlock()
+
+	for key, limiter := range targets.limiters {
+		depths[key] = int(limiter.waiting.Load())
+	}
+}
+
+// RateLimitQueueDepths returns how many messages are waiting for the rate limits. Messages waiting for a per-target
+// limit are counted under "network/target", messages waiting for the global limit are counted under the empty key.
+func (dazeus *DaZeus) RateLimitQueueDepths() map[string]int {
+	depths := make(map[string]int)
+	if dazeus.targetLimiters != nil {
+		dazeus.targetLimiters.queueDepths(depths)
+	}
+	if dazeus.limiter != nil {
+		depths[""] = int(dazeus.limiter.waiting.Load())
+	}
+
+	return depths
+}
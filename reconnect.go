@@ -0,0 +1,245 @@
+package dazeus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"syscall"
+	"time"
+)
+
+const (
+	// reconnectMinDelay is the delay before the first attempt to reconnect
+	reconnectMinDelay = time.Second
+	// reconnectMaxDelay is the maximum delay between two attempts to reconnect
+	reconnectMaxDelay = time.Minute
+)
+
+// ErrCoreRestarted is returned when the connection to the core was lost during a request that is not safe to send
+// again, so it is unknown whether the core handled it. Reconnecting must be enabled for this, and the connection has
+// been re-established when this is returned. Requests that only retrieve information, and subscribing to events or
+// commands are sent again automatically. Requests with side effects, such as sending messages or joining channels,
+// are not.
+var ErrCoreRestarted = errors.New("Connection to the core was lost during the request")
+
+// ErrReconnectExhausted is returned by Listen when the maximum number of attempts to reconnect failed
+var ErrReconnectExhausted = errors.New("Could not reconnect to the core")
+
+// reestablish tries to reconnect to the core after the failed connection was lost, backing off exponentially between
+// attempts. Nothing happens when the connection was already re-established by someone else.
+func (dazeus *DaZeus) reestablish(ctx context.Context, cause error, failed *connection) error {
+	if dazeus.dial == nil {
+		return cause
+	}
+
+	dazeus.reconnectMutex.Lock()
+	defer dazeus.reconnectMutex.Unlock()
+
+	if dazeus.connection.Load() != failed {
+		return nil
+	}
+
+	// a client that gave up reconnecting stays terminated
+	select {
+	case <-dazeus.done:
+		return cause
+	default:
+	}
+
+	dazeus.logger.Printf("Lost connection to the core: %v", cause)
+
+	dazeus.reconnecting.Store(true)
+	defer dazeus.reconnecting.Store(false)
+
+	err := cause
+	delay := reconnectMinDelay
+	for attempt := 1; dazeus.maxReconnects <= 0 || attempt <= dazeus.maxReconnects; attempt++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-dazeus.clock.After(delay):
+		}
+
+		if dazeus.isClosed() {
+			return cause
+		}
+
+		dazeus.logger.Printf("Reconnecting to the core (attempt %d)", attempt)
+		err = dazeus.redial()
+		if err == nil {
+			dazeus.reportStatus("Reconnected to the core after %d attempt(s)", attempt)
+			dazeus.afterReconnect()
+			return nil
+		}
+
+		dazeus.logger.Printf("Reconnecting failed: %v", err)
+		delay *= 2
+		if delay > reconnectMaxDelay {
+			delay = reconnectMaxDelay
+		}
+	}
+
+	dazeus.reportStatus("Giving up reconnecting to the core after %d attempts", dazeus.maxReconnects)
+	dazeus.terminate()
+	return fmt.Errorf("%w after %d attempts: %v", ErrReconnectExhausted, dazeus.maxReconnects, err)
+}
+
+// afterReconnect refreshes the cached state and lets the plugin restore its own state after reconnecting
+func (dazeus *DaZeus) afterReconnect() {
+	if dazeus.trackState {
+		err := dazeus.Refresh()
+		if err != nil {
+			dazeus.logger.Printf("Could not refresh the cached state after reconnecting: %v", err)
+		}
+	}
+
+	if dazeus.onReconnect != nil {
+		dazeus.onReconnect(dazeus)
+	}
+}
+
+// redial replaces the connection by a new one and restores all subscriptions on it
+func (dazeus *DaZeus) redial() error {
+	conn, err := dazeus.dial()
+	if err != nil {
+		return err
+	}
+
+	// wait for the reader of the old connection to stop before the new reader takes over the read buffer
+	old := dazeus.connection.Load()
+	old.conn.Close()
+	<-old.done
+	dazeus.buffer.Reset()
+
+	c := newConnection(conn)
+	dazeus.writeMutex.Lock()
+	dazeus.connection.Store(c)
+	dazeus.writeMutex.Unlock()
+	go dazeus.readLoop(c)
+
+	err = dazeus.sendHandshake()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	err = dazeus.replaySubscriptions()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	dazeus.stats.reconnects.Add(1)
+	return nil
+}
+
+// replaySubscriptions sends all subscriptions to the core again, in the order they were originally made
+func (dazeus *DaZeus) replaySubscriptions() error {
+	dazeus.listenersMutex.RLock()
+	handles := make([]ListenerHandle, 0, len(dazeus.listeners))
+	for handle := range dazeus.listeners {
+		handles = append(handles, handle)
+	}
+	sort.Slice(handles, func(i, j int) bool { return handles[i] < handles[j] })
+
+	listeners := make([]listener, 0, len(handles))
+	for _, handle := range handles {
+		listeners = append(listeners, dazeus.listeners[handle])
+	}
+
+	internalEvents := make([]eventType, 0, len(dazeus.internalEvents))
+	for event := range dazeus.internalEvents {
+		internalEvents = append(internalEvents, event)
+	}
+	dazeus.listenersMutex.RUnlock()
+
+	subscribed := make(map[eventType]bool)
+	for _, l := range listeners {
+		if l.event == EventCommand {
+			err := dazeus.registerCommands(context.Background(), l.commands, l.scope)
+			if err != nil {
+				return err
+			}
+		} else if !subscribed[l.event] {
+			_, err := writeForSuccessResponse(context.Background(), dazeus, map[string]interface{}{
+				"do":     "subscribe",
+				"params": []string{string(l.event)},
+			})
+			if err != nil {
+				return err
+			}
+			subscribed[l.event] = true
+		}
+	}
+
+	for _, event := range internalEvents {
+		if subscribed[event] {
+			continue
+		}
+
+		_, err := writeForSuccessResponse(context.Background(), dazeus, map[string]interface{}{
+			"do":     "subscribe",
+			"params": []string{string(event)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// canRecover indicates if a request that failed with the error can be recovered from by reconnecting
+func (dazeus *DaZeus) canRecover(err error) bool {
+	// requests made while reconnecting, such as replaying the subscriptions, are handled by the reconnect itself
+	if !dazeus.reconnect || dazeus.reconnecting.Load() || dazeus.isClosed() {
+		return false
+	}
+
+	return isConnectionError(err)
+}
+
+// isConnectionError indicates if an error means the connection to the core was lost
+func isConnectionError(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) || errors.As(err, &netErr)
+}
+
+// isRetryable indicates if a request can safely be sent again when it is unknown whether the core handled it
+func isRetryable(message Message) bool {
+	if message["get"] != nil {
+		return true
+	}
+
+	var action string
+	switch params := message["params"].(type) {
+	case []string:
+		if len(params) > 0 {
+			action = params[0]
+		}
+	case []interface{}:
+		if len(params) > 0 {
+			action, _ = params[0].(string)
+		}
+	}
+
+	switch message["do"] {
+	case "subscribe", "unsubscribe", "command":
+		return true
+	case "property":
+		return action == "get" || action == "keys"
+	case "permission":
+		return action == "has"
+	}
+
+	return false
+}
@@ -0,0 +1,140 @@
+package dazeus
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+// HighlightMode determines whether replies highlight the sender of an event
+type HighlightMode int
+
+const (
+	// HighlightAsRequested highlights the sender when the handler asks for it
+	HighlightAsRequested HighlightMode = iota
+	// HighlightAlways always highlights the sender
+	HighlightAlways
+	// HighlightNever never highlights the sender
+	HighlightNever
+)
+
+// ReplyDefaults configures how Event.Reply replies in a network
+type ReplyDefaults struct {
+	// Notice makes replies notices instead of normal messages
+	Notice bool
+	// Highlight determines whether the sender is highlighted
+	Highlight HighlightMode
+}
+
+// SetReplyDefaults sets how Event.Reply replies in some network, for example with notices instead of messages.
+func (dazeus *DaZeus) SetReplyDefaults(network string, defaults ReplyDefaults) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	if dazeus.replyDefaults == nil {
+		dazeus.replyDefaults = make(map[string]ReplyDefaults)
+	}
+	dazeus.replyDefaults[network] = defaults
+}
+
+// ReplyDefaultsFor returns how Event.Reply replies in some network
+func (dazeus *DaZeus) ReplyDefaultsFor(network string) ReplyDefaults {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	return dazeus.replyDefaults[network]
+}
+
+// SetReplyTargetResolver sets a function deciding where replies to events go, for example to route replies to
+// messages relayed from another network back through the relay. The replies of an event go to the network and
+// channel of the event when no resolver is set, which is restored by setting nil.
+func (dazeus *DaZeus) SetReplyTargetResolver(resolve func(Event) (network string, target string)) {
+	dazeus.settingsMutex.Lock()
+	defer dazeus.settingsMutex.Unlock()
+
+	dazeus.replyTargetResolver = resolve
+}
+
+// highlight decides whether to highlight, given what the handler asked for
+func (mode HighlightMode) highlight(requested bool) bool {
+	switch mode {
+	case HighlightAlways:
+		return true
+	case HighlightNever:
+		return false
+	}
+
+	return requested
+}
+
+// ReplyWriter streams the output of a handler back as replies to an event, see Event.ReplyWriter
+type ReplyWriter struct {
+	ctx    context.Context
+	event  *Event
+	pacer  *rateLimiter
+	buffer []byte
+}
+
+// ReplyWriter returns a writer that replies to the event with every complete line written to it, so handlers can
+// send output while it is being produced. The replies are paced by the configured rate limit, or by a conservative
+// default if no rate limit was configured. Empty lines are skipped. Close sends the last line if it was not
+// terminated by a newline.
+func (event *Event) ReplyWriter() *ReplyWriter {
+	return event.ReplyWriterContext(context.Background())
+}
+
+// ReplyWriterContext is like ReplyWriter, but writing fails with the error of the context once it is done, also while
+// waiting for the pacing
+func (event *Event) ReplyWriterContext(ctx context.Context) *ReplyWriter {
+	writer := &ReplyWriter{ctx: ctx, event: event}
+	if event.DaZeus.limiter == nil {
+		writer.pacer = newRateLimiter(defaultBroadcastInterval, defaultBroadcastBurst)
+	}
+
+	return writer
+}
+
+// Write sends all complete lines in p as replies, keeping an incomplete last line until more is written
+func (writer *ReplyWriter) Write(p []byte) (int, error) {
+	writer.buffer = append(writer.buffer, p...)
+
+	for {
+		end := bytes.IndexByte(writer.buffer, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+
+		line := writer.buffer[:end]
+		writer.buffer = writer.buffer[end+1:]
+
+		err := writer.reply(string(line))
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Close sends the remaining incomplete line, if any
+func (writer *ReplyWriter) Close() error {
+	line := string(writer.buffer)
+	writer.buffer = nil
+
+	return writer.reply(line)
+}
+
+// reply sends a single line as a reply
+func (writer *ReplyWriter) reply(line string) error {
+	line = strings.TrimRight(line, "\r")
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	if writer.pacer != nil {
+		err := writer.pacer.wait(writer.ctx, writer.event.DaZeus.clock)
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.event.ReplyContext(writer.ctx, line, false)
+}
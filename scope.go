@@ -1,6 +1,9 @@
 package dazeus
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+)
 
 // Scope for which a request is sent
 type Scope struct {
@@ -57,6 +60,56 @@ func (scope Scope) IsAll() bool {
 	return scope.Network == nil && scope.Receiver == nil && scope.Sender == nil
 }
 
+// Contains indicates if this scope encompasses the other scope, meaning that every field set in this scope is set to
+// the same value in the other scope. The universal scope contains all scopes.
+func (scope Scope) Contains(other Scope) bool {
+	return containsField(scope.Network, other.Network) &&
+		containsField(scope.Receiver, other.Receiver) &&
+		containsField(scope.Sender, other.Sender)
+}
+
+// containsField indicates if a scope field encompasses the same field in another scope
+func containsField(field *string, other *string) bool {
+	return field == nil || (other != nil && *field == *other)
+}
+
+// String returns the scope as network/receiver/sender, with * for the fields the scope is not limited to
+func (scope Scope) String() string {
+	return scopeField(scope.Network) + "/" + scopeField(scope.Receiver) + "/" + scopeField(scope.Sender)
+}
+
+func scopeField(field *string) string {
+	if field == nil {
+		return "*"
+	}
+
+	return *field
+}
+
+// scopeJSON is the JSON representation of a scope, with null for the fields the scope is not limited to
+type scopeJSON struct {
+	Network  *string `json:"network"`
+	Receiver *string `json:"receiver"`
+	Sender   *string `json:"sender"`
+}
+
+// MarshalJSON encodes the scope as an object with a network, receiver and sender field
+func (scope Scope) MarshalJSON() ([]byte, error) {
+	return json.Marshal(scopeJSON(scope))
+}
+
+// UnmarshalJSON decodes a scope encoded by MarshalJSON
+func (scope *Scope) UnmarshalJSON(data []byte) error {
+	var decoded scopeJSON
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+
+	*scope = Scope(decoded)
+	return nil
+}
+
 // ToSlice returns a slice for usage with permissions and properties
 func (scope Scope) ToSlice() []string {
 	s := make([]string, 0)
@@ -97,3 +150,68 @@ func (scope Scope) ToCommandSlice() ([]interface{}, error) {
 
 	return s, nil
 }
+
+// errNoNetwork, errNoReceiver and errNoSender are returned when building a scope for an event lacking a field
+var (
+	errNoNetwork  = errors.New("Event has no network to scope to")
+	errNoReceiver = errors.New("Event has no channel to scope to")
+	errNoSender   = errors.New("Event has no sender to scope to")
+)
+
+// NewSenderScope returns a scope limited to the network and sender of the event, or an error when the event has
+// no network or sender, such as CONNECT events.
+func (event *Event) NewSenderScope() (Scope, error) {
+	if event.Network == "" {
+		return Scope{}, errNoNetwork
+	}
+	if event.Sender == "" {
+		return Scope{}, errNoSender
+	}
+
+	return NewSenderScope(event.Network, event.Sender), nil
+}
+
+// NewReceiverScope returns a scope limited to the network and channel of the event, or an error when the event has
+// no network or channel.
+func (event *Event) NewReceiverScope() (Scope, error) {
+	if event.Network == "" {
+		return Scope{}, errNoNetwork
+	}
+	if event.Channel == "" {
+		return Scope{}, errNoReceiver
+	}
+
+	return NewReceiverScope(event.Network, event.Channel), nil
+}
+
+// NewScope returns a scope limited to the network, channel and sender of the event, or an error when the event
+// lacks one of them.
+func (event *Event) NewScope() (Scope, error) {
+	_, err := event.NewReceiverScope()
+	if err != nil {
+		return Scope{}, err
+	}
+	if event.Sender == "" {
+		return Scope{}, errNoSender
+	}
+
+	return NewScope(event.Network, event.Channel, event.Sender), nil
+}
+
+// Scope returns the scope limited to the network, channel and sender of the event, for checking permissions and
+// reading properties for the exact context of the event. Unlike NewScope it does not check the event has them.
+func (event *Event) Scope() Scope {
+	return NewScope(event.Network, event.Channel, event.Sender)
+}
+
+// SenderScope returns the scope limited to the network and sender of the event. Unlike NewSenderScope it does not
+// check the event has them.
+func (event *Event) SenderScope() Scope {
+	return NewSenderScope(event.Network, event.Sender)
+}
+
+// ReceiverScope returns the scope limited to the network and channel of the event. Unlike NewReceiverScope it does
+// not check the event has them.
+func (event *Event) ReceiverScope() Scope {
+	return NewReceiverScope(event.Network, event.Channel)
+}
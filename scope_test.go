@@ -0,0 +1,59 @@
+package dazeus
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestScopeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		scope  Scope
+		string string
+	}{
+		{"universal", NewUniversalScope(), "*/*/*"},
+		{"network", NewNetworkScope("net"), "net/*/*"},
+		{"receiver", NewReceiverScope("net", "#chan"), "net/#chan/*"},
+		{"sender", NewSenderScope("net", "nick"), "net/*/nick"},
+		{"full", NewScope("net", "#chan", "nick"), "net/#chan/nick"},
+		{"empty fields", NewScope("", "", ""), "//"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if str := test.scope.String(); str != test.string {
+				t.Errorf("String() = %q, want %q", str, test.string)
+			}
+
+			data, err := json.Marshal(test.scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded Scope
+			err = json.Unmarshal(data, &decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(decoded, test.scope) {
+				t.Errorf("%s decoded to %v, want %v", data, decoded, test.scope)
+			}
+		})
+	}
+}
+
+func TestEventScopes(t *testing.T) {
+	evt := Event{Network: "net", Channel: "#chan", Sender: "nick"}
+
+	if scope := evt.Scope(); scope.String() != "net/#chan/nick" {
+		t.Errorf("Scope() = %v", scope)
+	}
+	if scope := evt.SenderScope(); scope.String() != "net/*/nick" {
+		t.Errorf("SenderScope() = %v", scope)
+	}
+	if scope := evt.ReceiverScope(); scope.String() != "net/#chan/*" {
+		t.Errorf("ReceiverScope() = %v", scope)
+	}
+}
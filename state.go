@@ -0,0 +1,379 @@
+package dazeus
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// trackedEvents are the events needed to keep the cached state up to date
+var trackedEvents = []eventType{EventJoin, EventPart, EventKick, EventQuit, EventNick, EventNames, EventMode, EventNumeric}
+
+// networkState caches what is known about the bot in a network
+type networkState struct {
+	nick     string
+	channels map[string]*channelState
+}
+
+// channelState caches what is known about a channel the bot is in
+type channelState struct {
+	name    string
+	members map[string]*member
+}
+
+// member is a user in a channel
+type member struct {
+	nick     string
+	prefixes string
+}
+
+// memberPrefixes are the characters used in NAMES replies to indicate channel privileges, highest privilege first
+const memberPrefixes = "~&@%+"
+
+// memberModes are the channel modes granting the privileges of memberPrefixes, in the same order
+const memberModes = "qaohv"
+
+// operatorPrefixes are the member prefixes of channel operators
+const operatorPrefixes = "~&@"
+
+// ErrUnknownChannel is returned when the cached state does not contain a channel, because the bot is not in it or
+// state tracking is disabled
+var ErrUnknownChannel = errors.New("Channel not found in the cached state")
+
+// BotState is a snapshot of the networks the bot is connected to, with its nick and channels in each network
+type BotState struct {
+	Networks []string
+	Nicks    map[string]string
+	Channels map[string][]string
+}
+
+// Bootstrap fetches the networks, and the nick of the bot and the channels it is in for every network. The nicks and
+// channels of all networks are requested at once, so this takes two round trips to the core regardless of the number
+// of networks. The result also replaces the cached state.
+func (dazeus *DaZeus) Bootstrap() (*BotState, error) {
+	networks, err := dazeus.Networks()
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]Message, 0, 2*len(networks))
+	for _, network := range networks {
+		requests = append(requests,
+			Message{"get": "nick", "params": []string{network}},
+			Message{"get": "channels", "params": []string{network}},
+		)
+	}
+
+	responses, errs, err := writeForSuccessResponses(context.Background(), dazeus, requests)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &BotState{
+		Networks: networks,
+		Nicks:    make(map[string]string, len(networks)),
+		Channels: make(map[string][]string, len(networks)),
+	}
+
+	state := make(map[string]*networkState, len(networks))
+	for i, network := range networks {
+		err = errors.Join(errs[2*i], errs[2*i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		nick, ok := responses[2*i]["nick"].(string)
+		if !ok {
+			return nil, errors.New("No nick found in response")
+		}
+
+		channels, err := makeStringArray(responses[2*i+1]["channels"])
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.Nicks[network] = nick
+		snapshot.Channels[network] = channels
+
+		ns := &networkState{
+			nick:     nick,
+			channels: make(map[string]*channelState, len(channels)),
+		}
+		for _, channel := range channels {
+			ns.channels[CaseRFC1459.Fold(channel)] = newChannelState(channel)
+		}
+		state[network] = ns
+	}
+
+	dazeus.cacheMutex.Lock()
+	dazeus.state = state
+	dazeus.cacheMutex.Unlock()
+
+	return snapshot, nil
+}
+
+// Refresh fetches the nick of the bot and the channels it is in again for every network, replacing the cached state.
+// The members of the channels are requested too, they are updated as soon as the core sends them.
+func (dazeus *DaZeus) Refresh() error {
+	snapshot, err := dazeus.Bootstrap()
+	if err != nil {
+		return err
+	}
+
+	err = dazeus.subscribeInternally(EventNames)
+	if err != nil {
+		return err
+	}
+
+	for network, channels := range snapshot.Channels {
+		for _, channel := range channels {
+			err = dazeus.Names(network, channel)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// startTracking subscribes to all events needed to keep the cached state up to date and fills the cache
+func (dazeus *DaZeus) startTracking() error {
+	for _, event := range trackedEvents {
+		err := dazeus.subscribeInternally(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return dazeus.Refresh()
+}
+
+// ChannelMembers returns the nicks of the users in a channel, as far as they are known from the cached state.
+func (dazeus *DaZeus) ChannelMembers(network string, channel string) []string {
+	dazeus.cacheMutex.Lock()
+	defer dazeus.cacheMutex.Unlock()
+
+	cs := dazeus.channelState(network, channel)
+	if cs == nil {
+		return nil
+	}
+
+	nicks := make([]string, 0, len(cs.members))
+	for _, m := range cs.members {
+		nicks = append(nicks, m.nick)
+	}
+	sort.Strings(nicks)
+
+	return nicks
+}
+
+// UserChannels returns the channels the bot shares with a user, as far as they are known from the cached state.
+func (dazeus *DaZeus) UserChannels(network string, nick string) []string {
+	dazeus.cacheMutex.Lock()
+	defer dazeus.cacheMutex.Unlock()
+
+	ns, ok := dazeus.state[network]
+	if !ok {
+		return nil
+	}
+
+	channels := make([]string, 0)
+	for _, cs := range ns.channels {
+		if _, ok := cs.members[CaseRFC1459.Fold(nick)]; ok {
+			channels = append(channels, cs.name)
+		}
+	}
+	sort.Strings(channels)
+
+	return channels
+}
+
+// BotPrefix returns the prefix indicating the highest privilege of the bot in a channel, such as "@" when it is a
+// channel operator or "+" when it is voiced, as far as it is known from the cached state. It is empty when the bot
+// has no privileges or the channel is unknown.
+func (dazeus *DaZeus) BotPrefix(network string, channel string) string {
+	prefix, _ := dazeus.botPrefix(network, channel)
+	return prefix
+}
+
+// IsOperator indicates if the bot is a channel operator in a channel, as far as it is known from the cached state.
+// It returns ErrUnknownChannel when the cached state does not contain the channel.
+func (dazeus *DaZeus) IsOperator(network string, channel string) (bool, error) {
+	prefix, err := dazeus.botPrefix(network, channel)
+	if err != nil {
+		return false, err
+	}
+
+	return prefix != "" && strings.Contains(operatorPrefixes, prefix), nil
+}
+
+// botPrefix returns the prefix of the highest privilege of the bot in a channel
+func (dazeus *DaZeus) botPrefix(network string, channel string) (string, error) {
+	dazeus.cacheMutex.Lock()
+	defer dazeus.cacheMutex.Unlock()
+
+	cs := dazeus.channelState(network, channel)
+	if cs == nil {
+		return "", ErrUnknownChannel
+	}
+
+	m, ok := cs.members[CaseRFC1459.Fold(dazeus.state[network].nick)]
+	if !ok || m.prefixes == "" {
+		return "", nil
+	}
+
+	return m.prefixes[:1], nil
+}
+
+func newChannelState(name string) *channelState {
+	return &channelState{
+		name:    name,
+		members: make(map[string]*member),
+	}
+}
+
+// channelState returns the cached state of a channel, the cache mutex must be held
+func (dazeus *DaZeus) channelState(network string, channel string) *channelState {
+	ns, ok := dazeus.state[network]
+	if !ok {
+		return nil
+	}
+
+	return ns.channels[CaseRFC1459.Fold(channel)]
+}
+
+// updateState applies an event to the cached state
+func (dazeus *DaZeus) updateState(evt Event) {
+	dazeus.cacheMutex.Lock()
+	defer dazeus.cacheMutex.Unlock()
+
+	if evt.Event == EventNumeric && evt.Channel == "005" {
+		dazeus.updateSupport(evt.Network, evt.Params)
+	}
+
+	ns, ok := dazeus.state[evt.Network]
+	if !ok {
+		return
+	}
+
+	nick, _, _, _ := ParseHostmask(evt.Sender)
+	isBot := CaseRFC1459.Equal(nick, ns.nick)
+
+	switch evt.Event {
+	case EventNames:
+		cs := ns.channels[CaseRFC1459.Fold(evt.Channel)]
+		if cs == nil {
+			return
+		}
+
+		cs.members = make(map[string]*member, len(evt.Params))
+		for _, name := range evt.Params {
+			for _, name := range strings.Fields(name) {
+				nick := strings.TrimLeft(name, memberPrefixes)
+				cs.members[CaseRFC1459.Fold(nick)] = &member{
+					nick:     nick,
+					prefixes: name[:len(name)-len(nick)],
+				}
+			}
+		}
+	case EventJoin:
+		if isBot {
+			ns.channels[CaseRFC1459.Fold(evt.Channel)] = newChannelState(evt.Channel)
+		}
+		if cs := ns.channels[CaseRFC1459.Fold(evt.Channel)]; cs != nil {
+			cs.members[CaseRFC1459.Fold(nick)] = &member{nick: nick}
+		}
+	case EventPart:
+		ns.removeMember(evt.Channel, nick)
+	case EventKick:
+		if len(evt.Params) > 0 {
+			ns.removeMember(evt.Channel, evt.Params[0])
+		}
+	case EventQuit:
+		for _, cs := range ns.channels {
+			delete(cs.members, CaseRFC1459.Fold(nick))
+		}
+	case EventMode:
+		cs := ns.channels[CaseRFC1459.Fold(evt.Channel)]
+		if cs == nil || len(evt.Params) == 0 {
+			return
+		}
+
+		cs.applyModes(evt.Params[0], evt.Params[1:])
+	case EventNick:
+		if evt.Channel == "" {
+			return
+		}
+
+		if isBot {
+			ns.nick = evt.Channel
+		}
+		for _, cs := range ns.channels {
+			if m, ok := cs.members[CaseRFC1459.Fold(nick)]; ok {
+				delete(cs.members, CaseRFC1459.Fold(nick))
+				m.nick = evt.Channel
+				cs.members[CaseRFC1459.Fold(evt.Channel)] = m
+			}
+		}
+	}
+}
+
+// removeMember removes a user from a channel, forgetting the channel when the user is the bot itself
+func (ns *networkState) removeMember(channel string, nick string) {
+	if CaseRFC1459.Equal(nick, ns.nick) {
+		delete(ns.channels, CaseRFC1459.Fold(channel))
+		return
+	}
+
+	if cs := ns.channels[CaseRFC1459.Fold(channel)]; cs != nil {
+		delete(cs.members, CaseRFC1459.Fold(nick))
+	}
+}
+
+// applyModes updates the privileges of the members of a channel for a mode change such as "+ov-v nick1 nick2 nick3"
+func (cs *channelState) applyModes(modes string, args []string) {
+	adding := true
+	for _, mode := range modes {
+		switch {
+		case mode == '+' || mode == '-':
+			adding = mode == '+'
+			continue
+		case strings.ContainsRune("beIk", mode) || (mode == 'l' && adding):
+			// modes that take an argument but do not change privileges
+			if len(args) > 0 {
+				args = args[1:]
+			}
+			continue
+		}
+
+		i := strings.IndexRune(memberModes, mode)
+		if i < 0 || len(args) == 0 {
+			continue
+		}
+
+		nick := args[0]
+		args = args[1:]
+
+		m, ok := cs.members[CaseRFC1459.Fold(nick)]
+		if !ok {
+			continue
+		}
+
+		prefix := memberPrefixes[i]
+		prefixes := strings.ReplaceAll(m.prefixes, string(prefix), "")
+		if adding {
+			prefixes += string(prefix)
+		}
+
+		// keep the prefixes ordered from the highest privilege to the lowest
+		ordered := ""
+		for _, p := range memberPrefixes {
+			if strings.ContainsRune(prefixes, p) {
+				ordered += string(p)
+			}
+		}
+		m.prefixes = ordered
+	}
+}
@@ -0,0 +1,63 @@
+package dazeus
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ConnStats is a snapshot of the statistics of a connection to the core
+type ConnStats struct {
+	// MessagesSent is the number of messages sent to the core
+	MessagesSent uint64
+	// MessagesReceived is the number of messages received from the core, both responses and events
+	MessagesReceived uint64
+	// BytesSent is the number of bytes sent to the core
+	BytesSent uint64
+	// BytesReceived is the number of bytes received from the core
+	BytesReceived uint64
+	// EventsDispatched is the number of events passed on to event handlers
+	EventsDispatched uint64
+	// DroppedEvents is the number of events dropped by event channels, see DroppedEvents
+	DroppedEvents uint64
+	// SkippedFrames is the number of malformed messages skipped, see SkippedFrames
+	SkippedFrames uint64
+	// Reconnects is the number of times the connection was re-established
+	Reconnects uint64
+	// RateLimitQueueDepths is the number of messages waiting for the rate limits, see RateLimitQueueDepths
+	RateLimitQueueDepths map[string]int
+	// PendingResponses is the number of requests waiting for a response
+	PendingResponses int
+	// QueuedEvents is the number of received events waiting to be handled by Listen
+	QueuedEvents int
+	// Uptime is how long ago the connection was created
+	Uptime time.Duration
+}
+
+// connStats are the counters behind ConnStats
+type connStats struct {
+	started          time.Time
+	messagesSent     atomic.Uint64
+	messagesReceived atomic.Uint64
+	bytesSent        atomic.Uint64
+	bytesReceived    atomic.Uint64
+	eventsDispatched atomic.Uint64
+	reconnects       atomic.Uint64
+}
+
+// Stats returns a snapshot of the statistics of the connection
+func (dazeus *DaZeus) Stats() ConnStats {
+	return ConnStats{
+		MessagesSent:         dazeus.stats.messagesSent.Load(),
+		MessagesReceived:     dazeus.stats.messagesReceived.Load(),
+		BytesSent:            dazeus.stats.bytesSent.Load(),
+		BytesReceived:        dazeus.stats.bytesReceived.Load(),
+		EventsDispatched:     dazeus.stats.eventsDispatched.Load(),
+		DroppedEvents:        dazeus.droppedEvents.Load(),
+		SkippedFrames:        dazeus.skippedFrames.Load(),
+		Reconnects:           dazeus.stats.reconnects.Load(),
+		RateLimitQueueDepths: dazeus.RateLimitQueueDepths(),
+		PendingResponses:     dazeus.PendingResponses(),
+		QueuedEvents:         dazeus.incoming.len(),
+		Uptime:               dazeus.clock.Now().Sub(dazeus.stats.started),
+	}
+}
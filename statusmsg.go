@@ -0,0 +1,57 @@
+package dazeus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrStatusPrefixNotSupported is returned when the server does not advertise a STATUSMSG prefix
+var ErrStatusPrefixNotSupported = errors.New("Status message prefix not supported by the server")
+
+// updateSupport records the tokens of an ISUPPORT (005) reply the library uses, the cache mutex must be held
+func (dazeus *DaZeus) updateSupport(network string, tokens []string) {
+	for _, token := range tokens {
+		value, ok := strings.CutPrefix(token, "STATUSMSG=")
+		if !ok {
+			continue
+		}
+
+		dazeus.setStatusPrefixes(network, value)
+	}
+}
+
+// SetStatusPrefixes sets the prefixes the server of a network supports for status messages, as listed in the
+// STATUSMSG token of its ISUPPORT reply, such as "@+". The server sends that reply only once when the bot connects,
+// so a plugin started later has to supply the prefixes itself.
+func (dazeus *DaZeus) SetStatusPrefixes(network string, prefixes string) {
+	dazeus.cacheMutex.Lock()
+	defer dazeus.cacheMutex.Unlock()
+
+	dazeus.setStatusPrefixes(network, prefixes)
+}
+
+// setStatusPrefixes records the status message prefixes of a network, the cache mutex must be held
+func (dazeus *DaZeus) setStatusPrefixes(network string, prefixes string) {
+	if dazeus.statusPrefixes == nil {
+		dazeus.statusPrefixes = make(map[string]string)
+	}
+	dazeus.statusPrefixes[network] = prefixes
+}
+
+// MessageToStatus sends a message to only the members of a channel with at least the privilege of a prefix, for
+// example only to the channel operators with prefix '@'. The prefix must be one of the status message prefixes of
+// the network, set with SetStatusPrefixes or WithStatusPrefixes, or learned from the ISUPPORT reply of the server
+// when state tracking is enabled while the bot connects. ErrStatusPrefixNotSupported is returned for other
+// prefixes.
+func (dazeus *DaZeus) MessageToStatus(network string, channel string, prefix byte, message string) error {
+	dazeus.cacheMutex.Lock()
+	prefixes, ok := dazeus.statusPrefixes[network]
+	dazeus.cacheMutex.Unlock()
+
+	if !ok || strings.IndexByte(prefixes, prefix) < 0 {
+		return fmt.Errorf("%w: %c", ErrStatusPrefixNotSupported, prefix)
+	}
+
+	return dazeus.Message(network, string(prefix)+channel, message)
+}
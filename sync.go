@@ -0,0 +1,108 @@
+package dazeus
+
+import (
+	"context"
+	"strings"
+)
+
+// WhoisResult is the outcome of a whois request
+type WhoisResult struct {
+	Network string
+	Nick    string
+	// Online indicates if the nick is in use, when it is not the core reported ERR_NOSUCHNICK for it
+	Online bool
+	// Identified indicates if the user is identified with services, as reported by the core
+	Identified bool
+	// Params are the parameters of the WHOIS event following the nick, empty when the nick is not online
+	Params []string
+}
+
+// WhoisSync sends a whois request for some nick in some network and waits for the result.
+func (dazeus *DaZeus) WhoisSync(network string, nick string) (*WhoisResult, error) {
+	return dazeus.WhoisSyncContext(context.Background(), network, nick)
+}
+
+// WhoisSyncContext is like WhoisSync, but gives up waiting when the context is done
+func (dazeus *DaZeus) WhoisSyncContext(ctx context.Context, network string, nick string) (*WhoisResult, error) {
+	// the WHOIS event is sent for unknown nicks too, but is preceded by an ERR_NOSUCHNICK numeric
+	err := dazeus.subscribeInternally(EventWhois)
+	if err != nil {
+		return nil, err
+	}
+	err = dazeus.subscribeInternally(EventNumeric)
+	if err != nil {
+		return nil, err
+	}
+
+	waiter := dazeus.addWaiter(func(evt Event) bool {
+		if evt.Network != network {
+			return false
+		}
+		if evt.Event == EventNumeric {
+			return evt.Channel == "401" && len(evt.Params) > 1 && CaseRFC1459.Equal(evt.Params[1], nick)
+		}
+		return evt.Event == EventWhois && CaseRFC1459.Equal(evt.Channel, nick)
+	})
+
+	err = dazeus.WhoisContext(ctx, network, nick)
+	if err != nil {
+		dazeus.removeWaiter(waiter)
+		return nil, err
+	}
+
+	evt, err := waitForWaiter(ctx, dazeus, waiter, defaultWaitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WhoisResult{
+		Network: network,
+		Nick:    nick,
+		Online:  evt.Event == EventWhois,
+	}
+	if result.Online {
+		result.Nick = evt.Channel
+		result.Params = evt.Params
+		result.Identified = len(evt.Params) > 0 && evt.Params[0] == "true"
+	}
+
+	return result, nil
+}
+
+// NamesSync sends a names request to some channel in some network and waits for the nicks in that channel.
+// The nicks are returned without the prefixes indicating their channel privileges.
+func (dazeus *DaZeus) NamesSync(network string, channel string) ([]string, error) {
+	return dazeus.NamesSyncContext(context.Background(), network, channel)
+}
+
+// NamesSyncContext is like NamesSync, but gives up waiting when the context is done
+func (dazeus *DaZeus) NamesSyncContext(ctx context.Context, network string, channel string) ([]string, error) {
+	err := dazeus.subscribeInternally(EventNames)
+	if err != nil {
+		return nil, err
+	}
+
+	waiter := dazeus.addWaiter(func(evt Event) bool {
+		return evt.Event == EventNames && evt.Network == network && CaseRFC1459.Equal(evt.Channel, channel)
+	})
+
+	err = dazeus.NamesContext(ctx, network, channel)
+	if err != nil {
+		dazeus.removeWaiter(waiter)
+		return nil, err
+	}
+
+	evt, err := waitForWaiter(ctx, dazeus, waiter, defaultWaitTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	nicks := make([]string, 0, len(evt.Params))
+	for _, names := range evt.Params {
+		for _, name := range strings.Fields(names) {
+			nicks = append(nicks, strings.TrimLeft(name, memberPrefixes))
+		}
+	}
+
+	return nicks, nil
+}
@@ -0,0 +1,222 @@
+package dazeus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// defaultLineLength is the number of bytes a single IRC message is limited to, leaving room for the protocol framing
+	defaultLineLength = 400
+	// ellipsis marks text that was cut off
+	ellipsis = "…"
+	// columnSeparator separates the columns of a table
+	columnSeparator = "  "
+)
+
+// FormatTable aligns the cells of the rows into columns, returning one line per row. Columns are narrowed and their
+// cells cut off when the table would be wider than an IRC message allows. Use the FormatTable method of DaZeus to
+// respect the line length set by WithMaxLineLength.
+func FormatTable(rows [][]string) []string {
+	return formatTable(rows, defaultLineLength)
+}
+
+// FormatTable is like the FormatTable function, but fits the lines in the line length of this connection
+func (dazeus *DaZeus) FormatTable(rows [][]string) []string {
+	return formatTable(rows, dazeus.maxLineLength)
+}
+
+// formatTable aligns the cells of the rows into columns, narrowing the columns until every line fits in limit bytes
+func formatTable(rows [][]string, limit int) []string {
+	widths := make([]int, 0)
+	for _, row := range rows {
+		for i, cell := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	// narrow the widest column until the longest line fits, columns are aligned in characters but the limit is in bytes
+	for tableLength(rows, widths) > limit {
+		widest := 0
+		for i, width := range widths {
+			if width > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= 1 {
+			break
+		}
+		widths[widest]--
+	}
+
+	lines := make([]string, 0, len(rows))
+	for _, row := range rows {
+		lines = append(lines, truncate(formatRow(row, widths), limit))
+	}
+
+	return lines
+}
+
+// formatRow pads and cuts off the cells of a row to the column widths, in characters
+func formatRow(row []string, widths []int) string {
+	var line strings.Builder
+	for i, cell := range row {
+		if i > 0 {
+			line.WriteString(columnSeparator)
+		}
+
+		cell = truncateRunes(cell, widths[i])
+		line.WriteString(cell)
+		if i < len(row)-1 {
+			line.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell)))
+		}
+	}
+
+	return strings.TrimRight(line.String(), " ")
+}
+
+// tableLength returns the number of bytes of the longest line of the table with the given column widths
+func tableLength(rows [][]string, widths []int) int {
+	longest := 0
+	for _, row := range rows {
+		if n := len(formatRow(row, widths)); n > longest {
+			longest = n
+		}
+	}
+
+	return longest
+}
+
+// truncateRunes cuts off a string to at most n characters, ending it with an ellipsis when it was cut off
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	return string(runes[:n-1]) + ellipsis
+}
+
+// truncate cuts off a string to at most limit bytes without splitting a character, ending it with an ellipsis when
+// it was cut off
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	if limit < len(ellipsis) {
+		return ""
+	}
+
+	end := limit - len(ellipsis)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+
+	return s[:end] + ellipsis
+}
+
+// splitMessage breaks a message into lines of at most limit bytes, at newlines and otherwise between words. Words
+// longer than a line are broken up without splitting a character.
+func splitMessage(message string, limit int) []string {
+	lines := make([]string, 0)
+	for _, paragraph := range strings.Split(message, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && len(line)+1+len(word) <= limit {
+				line += " " + word
+				continue
+			}
+
+			if line != "" {
+				lines = append(lines, line)
+			}
+
+			for len(word) > limit && limit > 0 {
+				end := limit
+				for end > 0 && !utf8.RuneStart(word[end]) {
+					end--
+				}
+				if end == 0 {
+					// the limit is smaller than a single character
+					end = limit
+				}
+
+				lines = append(lines, word[:end])
+				word = word[end:]
+			}
+			line = word
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// ParseArgs splits a string into arguments like a shell does: arguments are separated by whitespace, single and
+// double quotes group words into a single argument and a backslash escapes the next character, except within single
+// quotes.
+func ParseArgs(s string) ([]string, error) {
+	args := make([]string, 0)
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case quote == '\'' && r == '\'':
+			quote = 0
+		case quote == '\'':
+			current.WriteRune(r)
+		case r == '\\':
+			if i+1 == len(runes) {
+				return nil, errors.New("Unfinished escape at end of arguments")
+			}
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+		case quote == '"' && r == '"':
+			quote = 0
+		case quote == '"':
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("Missing closing %c in arguments", quote)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
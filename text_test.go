@@ -0,0 +1,108 @@
+package dazeus
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// checkTable checks every line fits in limit bytes and the last column starts at the same character in every line
+func checkTable(t *testing.T, lines []string, limit int, last []string) {
+	t.Helper()
+
+	column := -1
+	for i, line := range lines {
+		if len(line) > limit {
+			t.Errorf("line %d is %d bytes, want at most %d", i, len(line), limit)
+		}
+
+		index := strings.LastIndex(line, columnSeparator+last[i])
+		if index < 0 {
+			t.Errorf("line %d %q lost its last column %q", i, line, last[i])
+			continue
+		}
+		start := utf8.RuneCountInString(line[:index])
+		if column >= 0 && start != column {
+			t.Errorf("line %d has its last column at %d, want %d", i, start, column)
+		}
+		column = start
+	}
+}
+
+func TestFormatTable(t *testing.T) {
+	lines := FormatTable([][]string{
+		{"name", "score"},
+		{"someone", "12"},
+	})
+
+	want := []string{"name     score", "someone  12"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("FormatTable() = %q, want %q", lines, want)
+	}
+}
+
+func TestFormatTableWide(t *testing.T) {
+	lines := FormatTable([][]string{
+		{strings.Repeat("a", 300), strings.Repeat("b", 300), "1"},
+		{"short", "short", "2"},
+	})
+
+	checkTable(t, lines, defaultLineLength, []string{"1", "2"})
+}
+
+func TestFormatTableMultibyte(t *testing.T) {
+	dazeus := &DaZeus{maxLineLength: 100}
+	lines := dazeus.FormatTable([][]string{
+		{strings.Repeat("é", 80), "1"},
+		{"ü", "2"},
+		{strings.Repeat("日本", 30), "3"},
+	})
+
+	checkTable(t, lines, 100, []string{"1", "2", "3"})
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+	}{
+		{"empty", "", []string{}},
+		{"only whitespace", " \t ", []string{}},
+		{"words", "  one two\tthree ", []string{"one", "two", "three"}},
+		{"double quotes", `say "hello world"`, []string{"say", "hello world"}},
+		{"single quotes", `say 'hello world'`, []string{"say", "hello world"}},
+		{"quotes within a word", `a"b c"d`, []string{"ab cd"}},
+		{"other quote within quotes", `"it's" '"a"'`, []string{"it's", `"a"`}},
+		{"empty double quotes", `a "" b`, []string{"a", "", "b"}},
+		{"empty single quotes", `''`, []string{""}},
+		{"escaped space", `hello\ world`, []string{"hello world"}},
+		{"escaped quote", `\"a`, []string{`"a`}},
+		{"escape within double quotes", `"a\"b"`, []string{`a"b`}},
+		{"no escape within single quotes", `'a\b'`, []string{`a\b`}},
+		{"escaped backslash", `a\\b`, []string{`a\b`}},
+		{"multibyte", "héllo 'wörld'", []string{"héllo", "wörld"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := ParseArgs(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, test.args) {
+				t.Errorf("ParseArgs(%q) = %q, want %q", test.input, args, test.args)
+			}
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	for _, input := range []string{`"unterminated`, `'unterminated`, `a "b' c`, `trailing\`} {
+		args, err := ParseArgs(input)
+		if err == nil {
+			t.Errorf("ParseArgs(%q) = %q, want an error", input, args)
+		}
+	}
+}
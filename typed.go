@@ -0,0 +1,252 @@
+package dazeus
+
+// JoinEvent is a user joining a channel
+type JoinEvent struct {
+	Event
+	Nick string
+}
+
+// PartEvent is a user leaving a channel
+type PartEvent struct {
+	Event
+	Nick   string
+	Reason string
+}
+
+// QuitEvent is a user disconnecting from the network
+type QuitEvent struct {
+	Event
+	Nick   string
+	Reason string
+}
+
+// NickEvent is a user changing their nick
+type NickEvent struct {
+	Event
+	OldNick string
+	NewNick string
+}
+
+// KickEvent is a user being kicked from a channel
+type KickEvent struct {
+	Event
+	Kicker string
+	Kicked string
+	Reason string
+}
+
+// TopicEvent is the topic of a channel being changed
+type TopicEvent struct {
+	Event
+	Topic string
+	SetBy string
+}
+
+// ModeEvent is a change of the modes of a channel or user
+type ModeEvent struct {
+	Event
+	Modes string
+	Args  []string
+}
+
+// PrivMsgEvent is a message to a channel or to the bot
+type PrivMsgEvent struct {
+	Event
+	Message string
+}
+
+// NoticeEvent is a notice to a channel or to the bot
+type NoticeEvent struct {
+	Event
+	Message string
+}
+
+// ActionEvent is a CTCP action to a channel or to the bot
+type ActionEvent struct {
+	Event
+	Message string
+}
+
+// CommandEvent is a command for the bot
+type CommandEvent struct {
+	Event
+	// Args is the text after the command
+	Args string
+}
+
+// NumericEvent is a numeric reply from the server
+type NumericEvent struct {
+	Event
+	Code string
+}
+
+// As fills target with the details of the event when it is a pointer to the typed event matching the type of the
+// event, such as a *JoinEvent for JOIN events. It returns false when target does not match the type of the event.
+func (event *Event) As(target interface{}) bool {
+	switch t := target.(type) {
+	case *JoinEvent:
+		if event.Event != EventJoin {
+			return false
+		}
+		*t = JoinEvent{Event: *event, Nick: event.Sender}
+	case *PartEvent:
+		if event.Event != EventPart {
+			return false
+		}
+		*t = PartEvent{Event: *event, Nick: event.Sender, Reason: event.param(0)}
+	case *QuitEvent:
+		if event.Event != EventQuit {
+			return false
+		}
+		*t = QuitEvent{Event: *event, Nick: event.Sender, Reason: event.QuitReason()}
+	case *NickEvent:
+		old, new, ok := event.NickChange()
+		if !ok {
+			return false
+		}
+		*t = NickEvent{Event: *event, OldNick: old, NewNick: new}
+	case *KickEvent:
+		if event.Event != EventKick {
+			return false
+		}
+		*t = KickEvent{Event: *event, Kicker: event.Sender, Kicked: event.param(0), Reason: event.param(1)}
+	case *TopicEvent:
+		_, topic, setBy, ok := event.TopicChange()
+		if !ok {
+			return false
+		}
+		*t = TopicEvent{Event: *event, Topic: topic, SetBy: setBy}
+	case *ModeEvent:
+		if event.Event != EventMode {
+			return false
+		}
+		var args []string
+		if len(event.Params) > 1 {
+			args = event.Params[1:]
+		}
+		*t = ModeEvent{Event: *event, Modes: event.param(0), Args: args}
+	case *PrivMsgEvent:
+		if event.Event != EventPrivMsg {
+			return false
+		}
+		*t = PrivMsgEvent{Event: *event, Message: event.param(0)}
+	case *NoticeEvent:
+		if event.Event != EventNotice {
+			return false
+		}
+		*t = NoticeEvent{Event: *event, Message: event.param(0)}
+	case *ActionEvent:
+		if event.Event != EventAction {
+			return false
+		}
+		*t = ActionEvent{Event: *event, Message: event.param(0)}
+	case *CommandEvent:
+		if event.Event != EventCommand {
+			return false
+		}
+		*t = CommandEvent{Event: *event, Args: event.param(0)}
+	case *NumericEvent:
+		if event.Event != EventNumeric {
+			return false
+		}
+		*t = NumericEvent{Event: *event, Code: event.Channel}
+	default:
+		return false
+	}
+
+	return true
+}
+
+// param returns a parameter of the event, or an empty string when the event has fewer parameters
+func (event *Event) param(i int) string {
+	if i >= len(event.Params) {
+		return ""
+	}
+
+	return event.Params[i]
+}
+
+// Switch calls the callback for the type of an event with the typed event, events of types without a callback go
+// to Default if it is set. Its Handle method can be subscribed as a handler to several event types at once.
+type Switch struct {
+	OnJoin    func(JoinEvent)
+	OnPart    func(PartEvent)
+	OnQuit    func(QuitEvent)
+	OnNick    func(NickEvent)
+	OnKick    func(KickEvent)
+	OnTopic   func(TopicEvent)
+	OnMode    func(ModeEvent)
+	OnPrivMsg func(PrivMsgEvent)
+	OnNotice  func(NoticeEvent)
+	OnAction  func(ActionEvent)
+	OnCommand func(CommandEvent)
+	OnNumeric func(NumericEvent)
+	Default   func(Event)
+}
+
+// Handle passes the event to the matching callback
+func (s *Switch) Handle(evt Event) {
+	if !s.dispatch(&evt) && s.Default != nil {
+		s.Default(evt)
+	}
+}
+
+// dispatch calls the callback for the type of the event, if there is one
+func (s *Switch) dispatch(evt *Event) bool {
+	switch {
+	case s.OnJoin != nil && evt.Event == EventJoin:
+		var typed JoinEvent
+		evt.As(&typed)
+		s.OnJoin(typed)
+	case s.OnPart != nil && evt.Event == EventPart:
+		var typed PartEvent
+		evt.As(&typed)
+		s.OnPart(typed)
+	case s.OnQuit != nil && evt.Event == EventQuit:
+		var typed QuitEvent
+		evt.As(&typed)
+		s.OnQuit(typed)
+	case s.OnNick != nil && evt.Event == EventNick:
+		var typed NickEvent
+		if !evt.As(&typed) {
+			return false
+		}
+		s.OnNick(typed)
+	case s.OnKick != nil && evt.Event == EventKick:
+		var typed KickEvent
+		evt.As(&typed)
+		s.OnKick(typed)
+	case s.OnTopic != nil && evt.Event == EventTopic:
+		var typed TopicEvent
+		evt.As(&typed)
+		s.OnTopic(typed)
+	case s.OnMode != nil && evt.Event == EventMode:
+		var typed ModeEvent
+		evt.As(&typed)
+		s.OnMode(typed)
+	case s.OnPrivMsg != nil && evt.Event == EventPrivMsg:
+		var typed PrivMsgEvent
+		evt.As(&typed)
+		s.OnPrivMsg(typed)
+	case s.OnNotice != nil && evt.Event == EventNotice:
+		var typed NoticeEvent
+		evt.As(&typed)
+		s.OnNotice(typed)
+	case s.OnAction != nil && evt.Event == EventAction:
+		var typed ActionEvent
+		evt.As(&typed)
+		s.OnAction(typed)
+	case s.OnCommand != nil && evt.Event == EventCommand:
+		var typed CommandEvent
+		evt.As(&typed)
+		s.OnCommand(typed)
+	case s.OnNumeric != nil && evt.Event == EventNumeric:
+		var typed NumericEvent
+		evt.As(&typed)
+		s.OnNumeric(typed)
+	default:
+		return false
+	}
+
+	return true
+}
@@ -1,6 +1,10 @@
 package dazeus
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+)
 
 // makeStringArray creates an array of strings from a value in the json message
 func makeStringArray(fieldValue interface{}) ([]string, error) {
@@ -22,3 +26,63 @@ func makeStringArray(fieldValue interface{}) ([]string, error) {
 
 	return strs, nil
 }
+
+// wildcardMatch indicates if a string matches a pattern, where * matches any number of characters and ? matches a
+// single character
+func wildcardMatch(pattern string, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	pi, si := 0, 0
+	star, backtrack := -1, 0
+
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]) && p[pi] != '*':
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, backtrack = pi, si
+			pi++
+		case star >= 0:
+			// let the last star match one more character
+			backtrack++
+			pi, si = star+1, backtrack
+		default:
+			return false
+		}
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+
+	return pi == len(p)
+}
+
+// Equal compares two messages by the JSON they are sent as, so the order of keys does not matter, slices of any type
+// equal slices of interface{} with the same elements, and numbers of any type equal when their values are equal.
+// Messages that can not be encoded as JSON are never equal.
+func (message Message) Equal(other Message) bool {
+	a, err := normalizeMessage(message)
+	if err != nil {
+		return false
+	}
+
+	b, err := normalizeMessage(other)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// normalizeMessage converts a message to the generic form it would have when received from the core
+func normalizeMessage(message Message) (interface{}, error) {
+	encoded, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	err = json.Unmarshal(encoded, &normalized)
+	return normalized, err
+}